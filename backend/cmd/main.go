@@ -1,9 +1,15 @@
 package main
 
 import (
+	"dnsmesh/internal/audit"
+	"dnsmesh/internal/auth"
 	"dnsmesh/internal/database"
 	"dnsmesh/internal/handlers"
+	"dnsmesh/internal/lint"
 	"dnsmesh/internal/middleware"
+	"dnsmesh/internal/oidc"
+	"dnsmesh/internal/propagation"
+	"dnsmesh/internal/webhook"
 	"dnsmesh/pkg/crypto"
 	"log"
 	"os"
@@ -28,12 +34,48 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// Record where the audit hash chain starts before anything is appended
+	if err := audit.EnsureChainState(database.DB); err != nil {
+		log.Fatalf("Failed to initialize audit chain: %v", err)
+	}
+
+	// Admins are only bootstrapped from RBAC_ADMIN_USERS
+	auth.WarnIfNoAdmin(database.DB)
+
+	// Link records to servers, creating servers for records that predate them
+	handlers.BackfillServers()
+
+	// Initialize optional native OIDC login
+	if err := oidc.Initialize(); err != nil {
+		log.Fatalf("Failed to initialize OIDC: %v", err)
+	}
+
+	// Record lint rules checked on changes and in reports
+	handlers.ConfigureLint(lint.ConfigFromEnv())
+
+	// Start background jobs
+	audit.StartRetention(database.DB, audit.RetentionConfigFromEnv())
+	webhook.Start(database.DB, webhook.ConfigFromEnv())
+	handlers.StartScheduler()
+	handlers.StartReaper()
+	handlers.StartPropagationChecks(propagation.ConfigFromEnv())
+	handlers.StartHealthChecker()
+
 	// Setup Gin
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Only trusted reverse proxies may assert identity or client IPs
+	proxyConfig, err := auth.ProxyConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid trusted proxy configuration: %v", err)
+	}
+
 	r := gin.Default()
+	if err := r.SetTrustedProxies(proxyConfig.CIDRs); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
 
 	// Setup CORS
 	r.Use(func(c *gin.Context) {
@@ -50,33 +92,121 @@ func main() {
 		c.Next()
 	})
 
-	// Public routes
-	r.GET("/api/auth/user", handlers.GetCurrentUser)
+	// Native OIDC login (only active when OIDC_ISSUER is set)
+	r.GET("/api/auth/oidc/login", handlers.OIDCLogin)
+	r.GET("/api/auth/oidc/callback", handlers.OIDCCallback)
+	r.POST("/api/auth/logout", handlers.Logout)
+
+	// ACME DNS-01 challenges (lego httpreq protocol, basic auth with ACME credentials)
+	r.POST("/api/acme/present", handlers.AcmePresent)
+	r.POST("/api/acme/cleanup", handlers.AcmeCleanup)
+
+	// dyndns2 updates for routers and NAS boxes (basic auth with DynDNS credentials)
+	r.GET("/nic/update", handlers.DynDNSUpdate)
 
-	// Protected routes
+	// Current user (role and permissions)
+	r.GET("/api/auth/user", middleware.AuthRequired(proxyConfig), handlers.GetCurrentUser)
+
+	// Protected routes, grouped by the permission they require
 	protected := r.Group("/api")
-	protected.Use(middleware.AuthRequired())
+	protected.Use(middleware.AuthRequired(proxyConfig))
 	{
-		// Provider routes
-		protected.GET("/providers", handlers.GetProviders)
-		protected.POST("/providers", handlers.CreateProvider)
-		protected.PUT("/providers/:id", handlers.UpdateProvider)
-		protected.DELETE("/providers/:id", handlers.DeleteProvider)
-		protected.POST("/providers/:id/sync", handlers.SyncProvider)
+		// Read-only routes (viewer and above)
+		viewer := protected.Group("", middleware.RequirePermission(auth.PermRead))
+		viewer.GET("/providers", handlers.GetProviders)
+		viewer.GET("/records", handlers.GetRecords)
+		viewer.GET("/records/dangling", handlers.GetDanglingRecords)
+		viewer.GET("/records/lint", handlers.GetRecordsLint)
+		viewer.POST("/records/:id/propagation", handlers.CheckRecordPropagation)
+		viewer.GET("/audit-logs", handlers.GetAuditLogs)
+		viewer.GET("/audit-logs/export", handlers.ExportAuditLogs)
+		viewer.GET("/events/stream", handlers.StreamEvents)
+
+		// Every user manages their own API tokens; admins see and revoke all
+		viewer.GET("/tokens", handlers.GetAPITokens)
+		viewer.POST("/tokens", handlers.CreateAPIToken)
+		viewer.DELETE("/tokens/:id", handlers.RevokeAPIToken)
+
+		// Four-eyes approval; reviewers need the permission of the change itself
+		viewer.GET("/change-requests", handlers.GetChangeRequests)
+		viewer.POST("/change-requests/:id/approve", handlers.ApproveChangeRequest)
+		viewer.POST("/change-requests/:id/reject", handlers.RejectChangeRequest)
+		viewer.POST("/change-requests/:id/cancel", handlers.CancelChangeRequest)
+
+		// Scheduled changes; the record permission is checked per operation
+		viewer.GET("/scheduled-changes", handlers.GetScheduledChanges)
+		viewer.POST("/scheduled-changes", handlers.CreateScheduledChange)
+		viewer.POST("/scheduled-changes/:id/cancel", handlers.CancelChangeRequest)
 
 		// DNS Record routes
-		protected.GET("/records", handlers.GetRecords)
-		protected.POST("/records", handlers.CreateRecord)
-		protected.PUT("/records/:id", handlers.UpdateRecord)
-		protected.POST("/records/:id/hide", handlers.HideRecord)
-		protected.POST("/records/:id/disable", handlers.DisableRecord)
-		protected.POST("/records/:id/enable", handlers.EnableRecord)
-		protected.DELETE("/records/:id", handlers.DeleteRecord)
-		protected.POST("/records/import", handlers.ImportRecords)
-		protected.POST("/records/reanalyze", handlers.ReanalyzeRecords)
-
-		// Audit log routes
-		protected.GET("/audit-logs", handlers.GetAuditLogs)
+		recordWriters := protected.Group("", middleware.RequirePermission(auth.PermRecordsWrite))
+		recordWriters.POST("/records", handlers.CreateRecord)
+		recordWriters.PUT("/records/:id", handlers.UpdateRecord)
+		recordWriters.POST("/records/import", handlers.ImportRecords)
+		recordWriters.POST("/records/batch", handlers.BatchRecords)
+
+		// Servers group records independently of their addresses
+		viewer.GET("/servers", handlers.GetServers)
+		viewer.GET("/servers/:id", handlers.GetServer)
+		recordWriters.POST("/servers", handlers.CreateServer)
+		recordWriters.PUT("/servers/:id", handlers.UpdateServer)
+		recordWriters.DELETE("/servers/:id", handlers.DeleteServer)
+		recordWriters.POST("/servers/:id/migrate", handlers.MigrateServer)
+		recordWriters.GET("/servers/:id/decommission", handlers.GetServerDecommission)
+		recordWriters.POST("/servers/:id/decommission", handlers.DecommissionServer)
+
+		// Health checks fail server records over to a standby address
+		viewer.GET("/health-checks", handlers.GetHealthChecks)
+		recordWriters.POST("/health-checks", handlers.CreateHealthCheck)
+		recordWriters.PUT("/health-checks/:id", handlers.UpdateHealthCheck)
+		recordWriters.DELETE("/health-checks/:id", handlers.DeleteHealthCheck)
+
+		recordTogglers := protected.Group("", middleware.RequirePermission(auth.PermRecordsToggle))
+		recordTogglers.POST("/records/:id/disable", handlers.DisableRecord)
+		recordTogglers.POST("/records/:id/enable", handlers.EnableRecord)
+
+		recordDeleters := protected.Group("", middleware.RequirePermission(auth.PermRecordsDelete))
+		recordDeleters.POST("/records/:id/hide", handlers.HideRecord)
+		recordDeleters.DELETE("/records/:id", handlers.DeleteRecord)
+
+		// Provider routes
+		syncers := protected.Group("", middleware.RequirePermission(auth.PermProvidersSync))
+		syncers.POST("/providers/:id/sync", handlers.SyncProvider)
+		syncers.POST("/records/reanalyze", handlers.ReanalyzeRecords)
+
+		providerManagers := protected.Group("", middleware.RequirePermission(auth.PermProvidersManage))
+		providerManagers.POST("/providers", handlers.CreateProvider)
+		providerManagers.PUT("/providers/:id", handlers.UpdateProvider)
+		providerManagers.DELETE("/providers/:id", handlers.DeleteProvider)
+
+		// Admin routes
+		admin := protected.Group("", middleware.RequirePermission(auth.PermAdmin))
+		admin.GET("/audit-logs/verify", handlers.VerifyAuditLogs)
+
+		admin.GET("/users", handlers.GetUsers)
+		admin.PUT("/users/:id/role", handlers.UpdateUserRole)
+
+		admin.GET("/zone-grants", handlers.GetZoneGrants)
+		admin.POST("/zone-grants", handlers.CreateZoneGrant)
+		admin.DELETE("/zone-grants/:id", handlers.DeleteZoneGrant)
+
+		admin.GET("/protected-zones", handlers.GetProtectedZones)
+		admin.POST("/protected-zones", handlers.CreateProtectedZone)
+		admin.DELETE("/protected-zones/:id", handlers.DeleteProtectedZone)
+
+		admin.GET("/acme-credentials", handlers.GetAcmeCredentials)
+		admin.POST("/acme-credentials", handlers.CreateAcmeCredential)
+		admin.DELETE("/acme-credentials/:id", handlers.DeleteAcmeCredential)
+
+		admin.GET("/dyndns-credentials", handlers.GetDynDNSCredentials)
+		admin.POST("/dyndns-credentials", handlers.CreateDynDNSCredential)
+		admin.DELETE("/dyndns-credentials/:id", handlers.DeleteDynDNSCredential)
+
+		admin.GET("/webhooks", handlers.GetWebhooks)
+		admin.POST("/webhooks", handlers.CreateWebhook)
+		admin.PUT("/webhooks/:id", handlers.UpdateWebhook)
+		admin.DELETE("/webhooks/:id", handlers.DeleteWebhook)
+		admin.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveries)
 	}
 
 	// Serve static files (frontend)
@@ -0,0 +1,282 @@
+// Command mock_oidc is a minimal OpenID Connect issuer for local development.
+// It signs ID tokens with a throwaway RSA key and lets you pick the username
+// and groups on its login page, so the native OIDC login and group-based zone
+// grants can be exercised without a real identity provider.
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const keyID = "mock-key-1"
+
+// authCode is an issued authorization code waiting to be redeemed
+type authCode struct {
+	ClientID    string
+	RedirectURI string
+	Nonce       string
+	Challenge   string
+	Username    string
+	Groups      []string
+	ExpiresAt   time.Time
+}
+
+type issuer struct {
+	url          string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!doctype html>
+<html><body style="font-family: sans-serif; max-width: 420px; margin: 60px auto">
+<h2>Mock OIDC login</h2>
+<form method="post" action="/authorize">
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}
+<p><label>Username<br><input name="username" value="{{.Username}}"></label></p>
+<p><label>Groups (comma-separated)<br><input name="groups" value="{{.Groups}}"></label></p>
+<button type="submit">Sign in</button>
+</form>
+</body></html>`))
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9400", "listen address")
+	issuerURL := flag.String("issuer", "http://127.0.0.1:9400", "issuer URL as seen by the backend and the browser")
+	clientID := flag.String("client-id", "dnsmesh", "expected client_id")
+	clientSecret := flag.String("client-secret", "", "expected client secret (empty accepts public clients)")
+	username := flag.String("user", "alice", "default username on the login page")
+	groups := flag.String("groups", "", "default comma-separated groups on the login page")
+	flag.Parse()
+
+	iss, err := newIssuer(*issuerURL, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+
+	log.Printf("Mock OIDC issuer %s listening on %s (client_id %s)", iss.url, *addr, iss.clientID)
+	log.Fatal(http.ListenAndServe(*addr, iss.handler(*username, *groups)))
+}
+
+// newIssuer creates an issuer with a fresh signing key
+func newIssuer(issuerURL, clientID, clientSecret string) (*issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return &issuer{
+		url:          strings.TrimSuffix(issuerURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]authCode),
+	}, nil
+}
+
+// handler serves the issuer endpoints; username and groups prefill the login page
+func (iss *issuer) handler(username, groups string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", iss.discovery)
+	mux.HandleFunc("/jwks", iss.jwks)
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			iss.authorize(w, r)
+			return
+		}
+		params := map[string]string{}
+		for name := range r.URL.Query() {
+			params[name] = r.URL.Query().Get(name)
+		}
+		loginPage.Execute(w, map[string]interface{}{"Params": params, "Username": username, "Groups": groups})
+	})
+	mux.HandleFunc("/token", iss.token)
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Signed out of mock issuer\n"))
+	})
+	return mux
+}
+
+func (iss *issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                iss.url,
+		"authorization_endpoint":                iss.url + "/authorize",
+		"token_endpoint":                        iss.url + "/token",
+		"jwks_uri":                              iss.url + "/jwks",
+		"end_session_endpoint":                  iss.url + "/logout",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (iss *issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := iss.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize issues a code for the submitted username and groups
+func (iss *issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("client_id") != iss.clientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("code_challenge_method") != "S256" || r.Form.Get("code_challenge") == "" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+	redirectURI, err := url.Parse(r.Form.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	var groups []string
+	for _, group := range strings.Split(r.Form.Get("groups"), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+
+	code := randomString()
+	iss.mu.Lock()
+	iss.codes[code] = authCode{
+		ClientID:    iss.clientID,
+		RedirectURI: redirectURI.String(),
+		Nonce:       r.Form.Get("nonce"),
+		Challenge:   r.Form.Get("code_challenge"),
+		Username:    strings.TrimSpace(r.Form.Get("username")),
+		Groups:      groups,
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+	iss.mu.Unlock()
+
+	query := redirectURI.Query()
+	query.Set("code", code)
+	query.Set("state", r.Form.Get("state"))
+	redirectURI.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token redeems a code after checking client credentials and the PKCE verifier
+func (iss *issuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, secret, hasBasic := r.BasicAuth()
+	if !hasBasic {
+		clientID, secret = r.Form.Get("client_id"), r.Form.Get("client_secret")
+	}
+	if clientID != iss.clientID || (iss.clientSecret != "" && secret != iss.clientSecret) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	iss.mu.Lock()
+	code, ok := iss.codes[r.Form.Get("code")]
+	delete(iss.codes, r.Form.Get("code"))
+	iss.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || time.Now().After(code.ExpiresAt) ||
+		code.RedirectURI != r.Form.Get("redirect_uri") ||
+		code.Challenge != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":                iss.url,
+		"sub":                "mock|" + code.Username,
+		"aud":                iss.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              code.Nonce,
+		"preferred_username": code.Username,
+		"email":              code.Username + "@example.com",
+		"name":               code.Username,
+		"groups":             code.Groups,
+	}
+
+	idToken, err := iss.sign(claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// sign produces an RS256 compact JWT
+func (iss *issuer) sign(claims map[string]interface{}) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, iss.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/handlers"
+	"dnsmesh/internal/middleware"
+	"dnsmesh/internal/oidc"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// testEnv is a backend with native OIDC login against a mock issuer
+type testEnv struct {
+	issuer  *issuer
+	idp     *httptest.Server
+	backend *httptest.Server
+	client  *http.Client
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "dnsmesh.db"))
+	t.Setenv("AUTH_BYPASS", "")
+	if err := database.Initialize(); err != nil {
+		t.Fatalf("database: %v", err)
+	}
+
+	iss, err := newIssuer("", "dnsmesh", "s3cret")
+	if err != nil {
+		t.Fatalf("issuer: %v", err)
+	}
+	idp := httptest.NewServer(iss.handler("alice", ""))
+	t.Cleanup(idp.Close)
+	iss.url = idp.URL
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/auth/oidc/login", handlers.OIDCLogin)
+	r.GET("/api/auth/oidc/callback", handlers.OIDCCallback)
+	r.GET("/api/auth/user", middleware.AuthRequired(&auth.ProxyConfig{}), handlers.GetCurrentUser)
+	backend := httptest.NewServer(r)
+	t.Cleanup(backend.Close)
+
+	previous := oidc.Default
+	oidc.Default = oidc.NewClient(&oidc.Config{
+		Issuer:        idp.URL,
+		ClientID:      "dnsmesh",
+		ClientSecret:  "s3cret",
+		RedirectURL:   backend.URL + "/api/auth/oidc/callback",
+		Scopes:        []string{"openid", "profile", "groups"},
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+		SessionSecret: testSecret,
+		SessionTTL:    time.Hour,
+		PostLoginURL:  "/",
+	})
+	t.Cleanup(func() { oidc.Default = previous })
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &testEnv{issuer: iss, idp: idp, backend: backend, client: client}
+}
+
+// beginLogin starts a login and returns the issuer's authorize URL
+func (env *testEnv) beginLogin(t *testing.T) *url.URL {
+	t.Helper()
+	resp, err := env.client.Get(env.backend.URL + "/api/auth/oidc/login?redirect=/records")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("login status = %d, want 302", resp.StatusCode)
+	}
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(authURL.String(), env.idp.URL+"/authorize") {
+		t.Fatalf("login redirected to %q", resp.Header.Get("Location"))
+	}
+	return authURL
+}
+
+// authorize signs in at the issuer and returns the callback URL with the code
+func (env *testEnv) authorize(t *testing.T, authURL *url.URL, username, groups string) *url.URL {
+	t.Helper()
+	form := authURL.Query()
+	form.Set("username", username)
+	form.Set("groups", groups)
+	resp, err := env.client.PostForm(env.idp.URL+"/authorize", form)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+	callback, _ := url.Parse(resp.Header.Get("Location"))
+	return callback
+}
+
+// cookie returns the named cookie the jar holds for path
+func (env *testEnv) cookie(t *testing.T, path, name string) string {
+	t.Helper()
+	u, _ := url.Parse(env.backend.URL + path)
+	for _, cookie := range env.client.Jar.Cookies(u) {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// currentUser calls /api/auth/user with only the given session cookie
+func (env *testEnv) currentUser(t *testing.T, session string) (int, map[string]interface{}) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, env.backend.URL+"/api/auth/user", nil)
+	if session != "" {
+		req.AddCookie(&http.Cookie{Name: oidc.SessionCookie, Value: session})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("user: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		User map[string]interface{} `json:"user"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.User
+}
+
+func TestLoginFlow(t *testing.T) {
+	env := newTestEnv(t)
+
+	authURL := env.beginLogin(t)
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorize URL lacks a PKCE challenge: %s", authURL)
+	}
+	if query.Get("state") == "" || query.Get("nonce") == "" {
+		t.Fatalf("authorize URL lacks state or nonce: %s", authURL)
+	}
+	if env.cookie(t, "/api/auth/oidc/callback", oidc.FlowCookie) == "" {
+		t.Fatal("login did not set the flow cookie")
+	}
+
+	callback := env.authorize(t, authURL, "alice", "ops, dev")
+	resp, err := env.client.Get(callback.String())
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/records" {
+		t.Fatalf("callback = %d to %q, want 302 to /records", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	session := env.cookie(t, "/", oidc.SessionCookie)
+	if session == "" {
+		t.Fatal("callback did not set the session cookie")
+	}
+	status, user := env.currentUser(t, session)
+	if status != http.StatusOK {
+		t.Fatalf("user status = %d, want 200", status)
+	}
+	if user["username"] != "alice" || user["auth_method"] != auth.MethodOIDC {
+		t.Fatalf("user = %v, want alice via oidc", user)
+	}
+	if groups, _ := user["groups"].([]interface{}); len(groups) != 2 || groups[0] != "ops" || groups[1] != "dev" {
+		t.Fatalf("groups = %v, want [ops dev]", user["groups"])
+	}
+
+	// The flow cookie is cleared, so the callback cannot be replayed
+	if env.cookie(t, "/api/auth/oidc/callback", oidc.FlowCookie) != "" {
+		t.Fatal("callback did not clear the flow cookie")
+	}
+	resp, err = env.client.Get(callback.String())
+	if err != nil {
+		t.Fatalf("callback replay: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("replayed callback status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestLoginRejectsStateMismatch(t *testing.T) {
+	env := newTestEnv(t)
+
+	callback := env.authorize(t, env.beginLogin(t), "alice", "")
+	query := callback.Query()
+	query.Set("state", "forged")
+	callback.RawQuery = query.Encode()
+
+	resp, err := env.client.Get(callback.String())
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("callback status = %d, want 401", resp.StatusCode)
+	}
+	if env.cookie(t, "/", oidc.SessionCookie) != "" {
+		t.Fatal("session cookie set despite state mismatch")
+	}
+}
+
+func TestTokenRequiresPKCEVerifier(t *testing.T) {
+	env := newTestEnv(t)
+
+	// The verifier never leaves the backend; guess one matching nothing
+	callback := env.authorize(t, env.beginLogin(t), "alice", "")
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {callback.Query().Get("code")},
+		"redirect_uri":  {env.backend.URL + "/api/auth/oidc/callback"},
+		"client_id":     {"dnsmesh"},
+		"client_secret": {"s3cret"},
+		"code_verifier": {"guessed-verifier"},
+	}
+	resp, err := http.PostForm(env.idp.URL+"/token", form)
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("token status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestMiddlewareRejectsBadSessions(t *testing.T) {
+	env := newTestEnv(t)
+
+	valid, err := oidc.Default.EncodeSession(&oidc.Session{
+		Subject:   "mock|bob",
+		Username:  "bob",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if status, user := env.currentUser(t, valid); status != http.StatusOK || user["username"] != "bob" {
+		t.Fatalf("valid session = %d %v, want 200 bob", status, user)
+	}
+
+	expired, _ := oidc.Default.EncodeSession(&oidc.Session{
+		Subject:   "mock|bob",
+		Username:  "bob",
+		ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	})
+	anonymous, _ := oidc.Default.EncodeSession(&oidc.Session{
+		Subject:   "mock|",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+	payload, sig, _ := strings.Cut(valid, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"mock|root","username":"root","exp":9999999999}`)) + "." + sig
+
+	for name, cookie := range map[string]string{
+		"none":      "",
+		"expired":   expired,
+		"anonymous": anonymous,
+		"forged":    forged,
+		"truncated": payload,
+	} {
+		if status, _ := env.currentUser(t, cookie); status != http.StatusUnauthorized {
+			t.Errorf("%s session status = %d, want 401", name, status)
+		}
+	}
+}
+
+func TestFlowCookieIsNotASession(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.beginLogin(t)
+	flow := env.cookie(t, "/api/auth/oidc/callback", oidc.FlowCookie)
+	if flow == "" {
+		t.Fatal("login did not set the flow cookie")
+	}
+
+	if _, err := oidc.Default.DecodeSession(flow); err == nil {
+		t.Fatal("flow cookie decoded as a session")
+	}
+	if status, _ := env.currentUser(t, flow); status != http.StatusUnauthorized {
+		t.Fatalf("flow cookie as session status = %d, want 401", status)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"dnsmesh/internal/audit"
+
+	"github.com/joho/godotenv"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	path := flag.String("db", getEnv("SQLITE_PATH", "data/dnsmesh.db"), "path to the SQLite database")
+	flag.Parse()
+
+	// Open read-only so verification can never modify the evidence
+	filePath := *path
+	if idx := strings.Index(filePath, "?"); idx >= 0 {
+		filePath = filePath[:idx]
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		fmt.Fprintln(os.Stderr, "open database:", err)
+		os.Exit(2)
+	}
+
+	db, err := gorm.Open(sqlite.Open("file:"+filePath+"?mode=ro"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "open database:", err)
+		os.Exit(2)
+	}
+
+	result, err := audit.Verify(db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "verify:", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("checked entries: %d\n", result.Checked)
+	if result.Legacy > 0 {
+		fmt.Printf("legacy entries (unhashed): %d\n", result.Legacy)
+	}
+	if result.AnchorHash != "" {
+		fmt.Printf("anchor hash: %s\n", result.AnchorHash)
+	}
+
+	if !result.Valid {
+		fmt.Printf("BROKEN at entry %d: %s\n", result.FirstBrokenID, result.Reason)
+		os.Exit(1)
+	}
+
+	fmt.Printf("head hash: %s\n", result.HeadHash)
+	fmt.Println("OK: audit chain intact")
+}
+
+// getEnv gets an environment variable with a fallback value
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,253 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"dnsmesh/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// appendMu serializes chain appends so two writers never link to the same parent
+var appendMu sync.Mutex
+
+// verifyBatchSize is the number of rows loaded per query while verifying
+const verifyBatchSize = 500
+
+// VerifyResult describes the outcome of walking the audit hash chain
+type VerifyResult struct {
+	Valid         bool   `json:"valid"`
+	Checked       int    `json:"checked"`
+	Legacy        int    `json:"legacy"`                    // rows written before hashing was introduced
+	HashedFromID  uint   `json:"hashed_from_id,omitempty"`  // first ID written with a hash
+	AnchorHash    string `json:"anchor_hash,omitempty"`     // prev_hash of the first hashed row
+	HeadHash      string `json:"head_hash,omitempty"`       // hash of the newest row
+	FirstBrokenID uint   `json:"first_broken_id,omitempty"` // first row that fails verification
+	Reason        string `json:"reason,omitempty"`
+}
+
+// ComputeHash returns the SHA-256 hash of an audit entry's content and its
+// link to the previous entry. Empty fields are skipped so that columns added
+// later do not change the hash of existing rows.
+func ComputeHash(entry *models.AuditLog) string {
+	fields := [][2]string{
+		{"prev_hash", entry.PrevHash},
+		{"created_at", entry.CreatedAt.UTC().Format(time.RFC3339Nano)},
+		{"action", entry.Action},
+		{"resource_type", entry.ResourceType},
+		{"resource_id", strconv.FormatUint(uint64(entry.ResourceID), 10)},
+		{"details", entry.Details},
+		{"changes", entry.Changes},
+		{"actor", entry.Actor},
+		{"auth_method", entry.AuthMethod},
+		{"ip_address", entry.IPAddress},
+		{"token_id", formatOptionalID(entry.TokenID)},
+		{"token_name", entry.TokenName},
+		{"batch_id", entry.BatchID},
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		// Length-prefix each value so field boundaries cannot be shifted
+		fmt.Fprintf(h, "%s:%d:%s\n", field[0], len(field[1]), field[1])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// formatOptionalID formats an ID, leaving zero empty so it is skipped when hashing
+func formatOptionalID(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// EnsureChainState records the hashing cutover on first start: the ID of the
+// oldest hashed row, or the next ID when no row carries a hash yet. Rows below
+// it are legacy; any hashless row at or above it is reported as tampering.
+func EnsureChainState(db *gorm.DB) error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	var state models.AuditChainState
+	err := db.First(&state).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load audit chain state: %w", err)
+	}
+
+	var firstHashed, maxID *uint
+	if err := db.Model(&models.AuditLog{}).Where("hash <> ''").Select("MIN(id)").Scan(&firstHashed).Error; err != nil {
+		return fmt.Errorf("failed to find the first hashed audit entry: %w", err)
+	}
+	if err := db.Model(&models.AuditLog{}).Select("MAX(id)").Scan(&maxID).Error; err != nil {
+		return fmt.Errorf("failed to find the newest audit entry: %w", err)
+	}
+
+	state.HashedFromID = 1
+	switch {
+	case firstHashed != nil:
+		state.HashedFromID = *firstHashed
+	case maxID != nil:
+		state.HashedFromID = *maxID + 1
+	}
+	return db.Create(&state).Error
+}
+
+// Append links the entry to the newest audit row and inserts it
+func Append(db *gorm.DB, entry *models.AuditLog) error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		return appendLocked(tx, entry, "")
+	})
+}
+
+// appendLocked inserts entry linked to the newest audit row, or to anchor
+// when the table is empty. Callers hold appendMu.
+func appendLocked(tx *gorm.DB, entry *models.AuditLog, anchor string) error {
+	var last models.AuditLog
+	err := tx.Select("id", "hash").Order("id DESC").First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load previous audit entry: %w", err)
+	}
+
+	entry.PrevHash = last.Hash
+	if err != nil {
+		entry.PrevHash = anchor
+	}
+	// Truncate to microseconds so the timestamp survives a round-trip through any backend
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	entry.Hash = ComputeHash(entry)
+
+	return tx.Create(entry).Error
+}
+
+// Verify walks the audit log from oldest to newest, recomputing each hash and
+// checking each link, and reports the first entry that breaks the chain.
+// The first hashed row must link to the last_hash of the newest prune entry,
+// or to nothing if the log was never pruned. Hashless rows below the recorded
+// hashing cutover are counted as legacy and skipped.
+func Verify(db *gorm.DB) (*VerifyResult, error) {
+	result := &VerifyResult{Valid: true}
+
+	hashedFromID, err := hashedFromID(db)
+	if err != nil {
+		return nil, err
+	}
+	result.HashedFromID = hashedFromID
+
+	anchor, err := expectedAnchor(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastID uint
+	var prevHash string
+	started := false
+
+	for {
+		var batch []models.AuditLog
+		if err := db.Where("id > ?", lastID).Order("id ASC").Limit(verifyBatchSize).Find(&batch).Error; err != nil {
+			return nil, fmt.Errorf("failed to load audit entries: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			lastID = entry.ID
+
+			if !started {
+				if entry.Hash == "" && entry.ID < hashedFromID {
+					result.Legacy++
+					continue
+				}
+				if entry.Hash != "" && entry.PrevHash != anchor {
+					result.fail(entry.ID, "first entry does not link to the recorded anchor (oldest entries deleted)")
+					return result, nil
+				}
+				started = true
+				result.AnchorHash = anchor
+				prevHash = anchor
+			}
+
+			result.Checked++
+
+			if entry.Hash == "" {
+				result.fail(entry.ID, "entry has no hash")
+				return result, nil
+			}
+			if entry.PrevHash != prevHash {
+				result.fail(entry.ID, "previous hash does not match the preceding entry (entry deleted or reordered)")
+				return result, nil
+			}
+			if ComputeHash(entry) != entry.Hash {
+				result.fail(entry.ID, "content hash mismatch (entry modified)")
+				return result, nil
+			}
+
+			prevHash = entry.Hash
+		}
+	}
+
+	result.HeadHash = prevHash
+	return result, nil
+}
+
+// hashedFromID returns the recorded hashing cutover, or 0 when none is
+// recorded, in which case no row may lack a hash
+func hashedFromID(db *gorm.DB) (uint, error) {
+	if !db.Migrator().HasTable(&models.AuditChainState{}) {
+		return 0, nil
+	}
+	var state models.AuditChainState
+	err := db.First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load audit chain state: %w", err)
+	}
+	return state.HashedFromID, nil
+}
+
+// expectedAnchor returns the hash the oldest remaining entry must link to:
+// the newest pruned entry's hash as recorded by the latest prune, or ""
+func expectedAnchor(db *gorm.DB) (string, error) {
+	var prune models.AuditLog
+	err := db.Where("action = ? AND resource_type = ?", models.ActionPrune, models.ResourceTypeAuditLog).
+		Order("id DESC").First(&prune).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load the latest prune entry: %w", err)
+	}
+
+	// Unreadable details fail the prune entry's own hash check
+	var details PruneResult
+	_ = json.Unmarshal([]byte(prune.Details), &details)
+	return details.LastHash, nil
+}
+
+func (r *VerifyResult) fail(id uint, reason string) {
+	r.Valid = false
+	r.FirstBrokenID = id
+	r.Reason = reason
+}
@@ -0,0 +1,237 @@
+package audit
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RetentionConfig controls how long audit logs are kept before being archived and pruned
+type RetentionConfig struct {
+	MaxAge     time.Duration // entries older than this are pruned; 0 disables age-based pruning
+	MaxRows    int           // only the newest MaxRows entries are kept; 0 disables count-based pruning
+	ArchiveDir string        // directory receiving compressed JSONL archives
+	Interval   time.Duration // how often the background job runs
+}
+
+// PruneResult summarizes a single retention run
+type PruneResult struct {
+	Deleted     int64  `json:"deleted"`
+	FirstID     uint   `json:"first_id,omitempty"`
+	LastID      uint   `json:"last_id,omitempty"`
+	LastHash    string `json:"last_hash,omitempty"` // hash of the newest pruned entry, i.e. the new chain anchor
+	ArchivePath string `json:"archive_path,omitempty"`
+}
+
+// Enabled reports whether any retention limit is configured
+func (cfg RetentionConfig) Enabled() bool {
+	return cfg.MaxAge > 0 || cfg.MaxRows > 0
+}
+
+// RetentionConfigFromEnv reads the retention policy from environment variables
+func RetentionConfigFromEnv() RetentionConfig {
+	cfg := RetentionConfig{
+		ArchiveDir: os.Getenv("AUDIT_ARCHIVE_DIR"),
+		Interval:   24 * time.Hour,
+	}
+	if cfg.ArchiveDir == "" {
+		cfg.ArchiveDir = "data/audit-archive"
+	}
+
+	if days, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS")); err == nil && days > 0 {
+		cfg.MaxAge = time.Duration(days) * 24 * time.Hour
+	}
+	if rows, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_MAX_ROWS")); err == nil && rows > 0 {
+		cfg.MaxRows = rows
+	}
+	if interval, err := time.ParseDuration(os.Getenv("AUDIT_RETENTION_INTERVAL")); err == nil && interval > 0 {
+		cfg.Interval = interval
+	}
+
+	return cfg
+}
+
+// StartRetention runs the retention policy once at startup and then on every interval
+func StartRetention(db *gorm.DB, cfg RetentionConfig) {
+	if !cfg.Enabled() {
+		log.Println("AuditRetention: No retention limit configured, audit logs are kept forever")
+		return
+	}
+
+	log.Printf("AuditRetention: Keeping audit logs for %s / %d rows, archiving to %s every %s",
+		cfg.MaxAge, cfg.MaxRows, cfg.ArchiveDir, cfg.Interval)
+
+	go func() {
+		for {
+			if _, err := Prune(db, cfg); err != nil {
+				log.Printf("AuditRetention: Run failed: %v", err)
+			}
+			time.Sleep(cfg.Interval)
+		}
+	}()
+}
+
+// Prune archives and deletes the oldest audit entries that fall outside the
+// retention policy. Entries are always removed as a contiguous prefix so the
+// remaining hash chain stays verifiable from its new anchor.
+func Prune(db *gorm.DB, cfg RetentionConfig) (*PruneResult, error) {
+	cutoffID, err := pruneCutoffID(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cutoffID == 0 {
+		return &PruneResult{}, nil
+	}
+
+	result, err := archiveEntries(db, cfg.ArchiveDir, cutoffID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive audit logs: %w", err)
+	}
+
+	// Delete and record the prune under the append lock in one transaction,
+	// so no entry links to a removed row. When every row is pruned, the prune
+	// entry links to the newest pruned one, which anchors the remaining chain.
+	appendMu.Lock()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		deleteResult := tx.Where("id <= ?", cutoffID).Delete(&models.AuditLog{})
+		if deleteResult.Error != nil {
+			return fmt.Errorf("failed to delete archived audit logs: %w", deleteResult.Error)
+		}
+		result.Deleted = deleteResult.RowsAffected
+
+		details, _ := json.Marshal(result)
+		if err := appendLocked(tx, &models.AuditLog{
+			Action:       models.ActionPrune,
+			ResourceType: models.ResourceTypeAuditLog,
+			Details:      string(details),
+			Actor:        "system:retention",
+			AuthMethod:   auth.MethodSystem,
+		}, result.LastHash); err != nil {
+			return fmt.Errorf("failed to record prune audit entry: %w", err)
+		}
+		return nil
+	})
+	appendMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("AuditRetention: Archived %d audit logs (id %d-%d) to %s",
+		result.Deleted, result.FirstID, result.LastID, result.ArchivePath)
+
+	// Reclaim the freed pages in the SQLite file; VACUUM rewrites the whole
+	// database, so skip it when nothing was deleted
+	if result.Deleted > 0 && db.Dialector.Name() == "sqlite" {
+		if err := db.Exec("VACUUM").Error; err != nil {
+			log.Printf("AuditRetention: VACUUM failed: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// pruneCutoffID returns the highest audit log ID that should be pruned, or 0 if none
+func pruneCutoffID(db *gorm.DB, cfg RetentionConfig) (uint, error) {
+	var cutoffID uint
+
+	if cfg.MaxAge > 0 {
+		var byAge *uint
+		if err := db.Model(&models.AuditLog{}).
+			Where("created_at < ?", time.Now().UTC().Add(-cfg.MaxAge)).
+			Select("MAX(id)").
+			Scan(&byAge).Error; err != nil {
+			return 0, fmt.Errorf("failed to find expired audit logs: %w", err)
+		}
+		if byAge != nil && *byAge > cutoffID {
+			cutoffID = *byAge
+		}
+	}
+
+	if cfg.MaxRows > 0 {
+		var entry models.AuditLog
+		err := db.Select("id").Order("id DESC").Offset(cfg.MaxRows).First(&entry).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("failed to find excess audit logs: %w", err)
+		}
+		if err == nil && entry.ID > cutoffID {
+			cutoffID = entry.ID
+		}
+	}
+
+	return cutoffID, nil
+}
+
+// archiveEntries writes every entry up to cutoffID into a gzip-compressed JSONL file.
+// The file is only moved into place once fully written and synced.
+func archiveEntries(db *gorm.DB, dir string, cutoffID uint) (*PruneResult, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".audit-archive-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	encoder := json.NewEncoder(gz)
+
+	result := &PruneResult{}
+	var lastID uint
+	for {
+		var batch []models.AuditLog
+		if err := db.Where("id > ? AND id <= ?", lastID, cutoffID).
+			Order("id ASC").
+			Limit(verifyBatchSize).
+			Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			if err := encoder.Encode(&batch[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		if result.FirstID == 0 {
+			result.FirstID = batch[0].ID
+		}
+		lastID = batch[len(batch)-1].ID
+		result.LastID = lastID
+		result.LastHash = batch[len(batch)-1].Hash
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("audit-%d-%d-%s.jsonl.gz", result.FirstID, result.LastID, time.Now().UTC().Format("20060102-150405"))
+	result.ArchivePath = filepath.Join(dir, name)
+	if err := os.Rename(tmp.Name(), result.ArchivePath); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"dnsmesh/internal/models"
+	"dnsmesh/pkg/crypto"
+
+	"gorm.io/gorm"
+)
+
+// ErrACMECredentialInvalid is returned for unknown users or wrong passwords
+var ErrACMECredentialInvalid = errors.New("invalid ACME credential")
+
+// GenerateCredentialSecret returns a new random basic auth password for ACME
+// or DynDNS credentials, together with its stored hash
+func GenerateCredentialSecret() (secret, hash string, err error) {
+	secret, err = crypto.RandomToken(24)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, HashAPIToken(secret), nil
+}
+
+// AuthenticateACMECredential checks a basic auth user and password against the stored credentials
+func AuthenticateACMECredential(db *gorm.DB, username, password string) (*models.AcmeCredential, error) {
+	var credential models.AcmeCredential
+	err := db.Where("username = ?", username).First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrACMECredentialInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ACME credential: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(HashAPIToken(password)), []byte(credential.SecretHash)) != 1 {
+		return nil, ErrACMECredentialInvalid
+	}
+
+	now := time.Now()
+	if credential.LastUsedAt == nil || now.Sub(*credential.LastUsedAt) > tokenLastUsedInterval {
+		db.Model(&credential).UpdateColumn("last_used_at", now)
+	}
+
+	return &credential, nil
+}
+
+// ACMECredentialAllows reports whether the credential may answer challenges for domain
+func ACMECredentialAllows(credential *models.AcmeCredential, domain string) bool {
+	return matchesZone(SplitList(credential.Domains), domain)
+}
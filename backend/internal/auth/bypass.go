@@ -3,11 +3,13 @@ package auth
 import (
 	"os"
 	"strings"
+
+	"dnsmesh/internal/models"
 )
 
 // BypassUser returns the local development user and true when auth bypass is enabled.
 func BypassUser() (string, bool) {
-	if !isTruthy(os.Getenv("AUTH_BYPASS")) {
+	if !IsTruthy(os.Getenv("AUTH_BYPASS")) {
 		return "", false
 	}
 
@@ -19,7 +21,8 @@ func BypassUser() (string, bool) {
 	return username, true
 }
 
-func isTruthy(value string) bool {
+// IsTruthy reports whether an environment flag value is enabled
+func IsTruthy(value string) bool {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case "1", "true", "yes", "y", "on":
 		return true
@@ -27,3 +30,11 @@ func isTruthy(value string) bool {
 		return false
 	}
 }
+
+// BypassRole returns the role granted to the bypass user (admin unless AUTH_BYPASS_ROLE is set).
+func BypassRole() string {
+	if role := strings.TrimSpace(os.Getenv("AUTH_BYPASS_ROLE")); IsValidRole(role) {
+		return role
+	}
+	return models.RoleAdmin
+}
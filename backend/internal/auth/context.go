@@ -0,0 +1,25 @@
+package auth
+
+// Context keys set by the authentication middleware
+const (
+	ContextUsername   = "username"
+	ContextAuthMethod = "auth_method"
+	ContextRole       = "role"
+	ContextGroups     = "groups"
+	ContextZoneScope  = "zone_scope"
+	ContextTokenID    = "token_id"
+	ContextTokenName  = "token_name"
+	ContextScopes     = "token_scopes"
+	ContextClaims     = "oidc_claims"
+)
+
+// Authentication methods recorded for each request
+const (
+	MethodRemoteUser = "remote_user"
+	MethodAPIToken   = "api_token"
+	MethodBypass     = "bypass"
+	MethodOIDC       = "oidc"
+	MethodACME       = "acme"   // ACME challenge credentials, only valid on /api/acme
+	MethodDynDNS     = "dyndns" // DynDNS credentials, only valid on /nic/update
+	MethodSystem     = "system" // background jobs acting without a request
+)
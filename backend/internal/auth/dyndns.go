@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"dnsmesh/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrDynDNSCredentialInvalid is returned for unknown users or wrong passwords
+var ErrDynDNSCredentialInvalid = errors.New("invalid DynDNS credential")
+
+// AuthenticateDynDNSCredential checks a basic auth user and password against the stored credentials
+func AuthenticateDynDNSCredential(db *gorm.DB, username, password string) (*models.DynDNSCredential, error) {
+	var credential models.DynDNSCredential
+	err := db.Where("username = ?", username).First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDynDNSCredentialInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DynDNS credential: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(HashAPIToken(password)), []byte(credential.SecretHash)) != 1 {
+		return nil, ErrDynDNSCredentialInvalid
+	}
+
+	now := time.Now()
+	if credential.LastUsedAt == nil || now.Sub(*credential.LastUsedAt) > tokenLastUsedInterval {
+		db.Model(&credential).UpdateColumn("last_used_at", now)
+	}
+
+	return &credential, nil
+}
+
+// DynDNSCredentialAllows reports whether the credential may update hostname
+func DynDNSCredentialAllows(credential *models.DynDNSCredential, hostname string) bool {
+	hostname = NormalizeDomain(hostname)
+	for _, allowed := range SplitList(credential.Hostnames) {
+		if NormalizeDomain(allowed) == hostname {
+			return true
+		}
+	}
+	return false
+}
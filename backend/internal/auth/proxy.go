@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultTrustedProxies only covers loopback; proxies on other hosts or
+// containers must be listed in TRUSTED_PROXIES, since any client on a private
+// network could otherwise assert any identity
+var defaultTrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+
+// ProxyConfig decides which peers may assert identity via Remote-User and group headers
+type ProxyConfig struct {
+	CIDRs        []string // trusted proxy networks as configured
+	networks     []*net.IPNet
+	Secret       string // optional shared secret the proxy must send
+	SecretHeader string // header carrying Secret
+	Debug        bool   // log identity headers on every request
+}
+
+// ProxyConfigFromEnv reads TRUSTED_PROXIES, TRUSTED_PROXY_SECRET,
+// TRUSTED_PROXY_SECRET_HEADER and AUTH_DEBUG
+func ProxyConfigFromEnv() (*ProxyConfig, error) {
+	cfg := &ProxyConfig{
+		CIDRs:        append([]string(nil), defaultTrustedProxies...),
+		Secret:       os.Getenv("TRUSTED_PROXY_SECRET"),
+		SecretHeader: strings.TrimSpace(os.Getenv("TRUSTED_PROXY_SECRET_HEADER")),
+		Debug:        IsTruthy(os.Getenv("AUTH_DEBUG")),
+	}
+	if cfg.SecretHeader == "" {
+		cfg.SecretHeader = "X-Proxy-Secret"
+	}
+
+	if value, ok := os.LookupEnv("TRUSTED_PROXIES"); ok {
+		cfg.CIDRs = SplitList(value)
+	}
+
+	for i, cidr := range cfg.CIDRs {
+		// Accept bare addresses as single-host networks
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %s", cidr)
+			}
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+			cfg.CIDRs[i] = cidr
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy network %s: %w", cidr, err)
+		}
+		cfg.networks = append(cfg.networks, network)
+	}
+
+	return cfg, nil
+}
+
+// Trusts reports whether the request came directly from a trusted proxy and,
+// when a shared secret is configured, carries it
+func (cfg *ProxyConfig) Trusts(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	trusted := false
+	for _, network := range cfg.networks {
+		if network.Contains(ip) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return false
+	}
+
+	if cfg.Secret != "" {
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get(cfg.SecretHeader)), []byte(cfg.Secret)) == 1
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"dnsmesh/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Permissions checked by the route groups
+const (
+	PermRead            = "read"             // view providers, records and audit logs
+	PermRecordsWrite    = "records:write"    // create, update and import records
+	PermRecordsToggle   = "records:toggle"   // enable and disable records
+	PermRecordsDelete   = "records:delete"   // hide and delete records
+	PermProvidersSync   = "providers:sync"   // sync providers and re-analyze records
+	PermProvidersManage = "providers:manage" // create, update and delete providers
+	PermAdmin           = "admin"            // users, webhooks and audit verification
+)
+
+// rolePermissions lists what each role may do
+var rolePermissions = map[string][]string{
+	models.RoleViewer: {PermRead},
+	models.RoleOperator: {
+		PermRead, PermRecordsWrite, PermRecordsToggle, PermRecordsDelete, PermProvidersSync,
+	},
+	models.RoleAdmin: {
+		PermRead, PermRecordsWrite, PermRecordsToggle, PermRecordsDelete, PermProvidersSync,
+		PermProvidersManage, PermAdmin,
+	},
+}
+
+// lastSeenInterval limits how often a user's last-seen timestamp is written
+const lastSeenInterval = 5 * time.Minute
+
+// IsValidRole reports whether role is a known role
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions returns the permissions granted to a role
+func RolePermissions(role string) []string {
+	return rolePermissions[role]
+}
+
+// RoleHasPermission reports whether role grants permission
+func RoleHasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsureUser loads the user record for username, creating it on first login
+// with the role mapped by InitialRole
+func EnsureUser(db *gorm.DB, username string) (*models.User, error) {
+	if strings.TrimSpace(username) == "" {
+		return nil, errors.New("empty username")
+	}
+
+	var user models.User
+	err := db.Where("username = ?", username).First(&user).Error
+	if err == nil {
+		if user.LastSeenAt == nil || time.Since(*user.LastSeenAt) > lastSeenInterval {
+			now := time.Now()
+			db.Model(&user).Update("last_seen_at", now)
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	role, err := InitialRole(username)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user = models.User{Username: username, Role: role, LastSeenAt: &now}
+	if err := db.Create(&user).Error; err != nil {
+		// A concurrent first request may have created the user already
+		if loadErr := db.Where("username = ?", username).First(&user).Error; loadErr == nil {
+			return &user, nil
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// RecordGroups stores the groups a user signed in with, so their API tokens
+// are scoped by the same zone grants
+func RecordGroups(db *gorm.DB, user *models.User, groups []string) error {
+	joined := strings.Join(groups, ",")
+	if user.Groups == joined {
+		return nil
+	}
+	if err := db.Model(user).Update("groups", joined).Error; err != nil {
+		return fmt.Errorf("failed to record groups: %w", err)
+	}
+	return nil
+}
+
+// InitialRole maps a first-time Remote-User login to a role. Users listed in
+// RBAC_ADMIN_USERS / RBAC_OPERATOR_USERS / RBAC_VIEWER_USERS get that role;
+// everyone else receives RBAC_DEFAULT_ROLE (viewer by default). Admins are
+// only ever bootstrapped from RBAC_ADMIN_USERS, never by being seen first.
+func InitialRole(username string) (string, error) {
+	mappings := []struct {
+		env  string
+		role string
+	}{
+		{"RBAC_ADMIN_USERS", models.RoleAdmin},
+		{"RBAC_OPERATOR_USERS", models.RoleOperator},
+		{"RBAC_VIEWER_USERS", models.RoleViewer},
+	}
+	for _, mapping := range mappings {
+		if listContains(os.Getenv(mapping.env), username) {
+			return mapping.role, nil
+		}
+	}
+
+	if role := strings.TrimSpace(os.Getenv("RBAC_DEFAULT_ROLE")); IsValidRole(role) {
+		return role, nil
+	}
+	return models.RoleViewer, nil
+}
+
+// WarnIfNoAdmin logs at startup when nobody can administer the installation
+// because no admin exists and RBAC_ADMIN_USERS is empty
+func WarnIfNoAdmin(db *gorm.DB) {
+	if strings.TrimSpace(os.Getenv("RBAC_ADMIN_USERS")) != "" {
+		return
+	}
+	var admins int64
+	if err := db.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins).Error; err != nil {
+		log.Printf("RBAC: Failed to count admins: %v", err)
+		return
+	}
+	if admins == 0 {
+		log.Println("RBAC: No admin exists; set RBAC_ADMIN_USERS to the usernames that should become admin on first login")
+	}
+}
+
+// listContains reports whether a comma-separated list contains value
+func listContains(list, value string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == value {
+			return true
+		}
+	}
+	return false
+}
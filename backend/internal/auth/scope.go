@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"dnsmesh/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// groupHeaders are the reverse-proxy headers carrying the user's groups
+var groupHeaders = []string{"X-Forwarded-Groups", "Remote-Groups", "X-Remote-Groups"}
+
+// userHeaders are the reverse-proxy headers carrying the username
+var userHeaders = []string{"Remote-User", "X-Remote-User", "X-Forwarded-User"}
+
+// IdentityHeaders returns the reverse-proxy headers that assert who the user
+// is, which are only honoured from trusted proxies
+func IdentityHeaders() []string {
+	return append(append([]string(nil), userHeaders...), groupHeaders...)
+}
+
+// ZoneScope restricts which domains a request may view or edit.
+// Zones are domain suffixes: a grant on pay.example.com covers
+// pay.example.com and every name below it.
+type ZoneScope struct {
+	Unrestricted bool     `json:"unrestricted"`
+	ViewZones    []string `json:"view_zones,omitempty"`
+	EditZones    []string `json:"edit_zones,omitempty"`
+}
+
+// UnrestrictedScope returns a scope covering every zone
+func UnrestrictedScope() *ZoneScope {
+	return &ZoneScope{Unrestricted: true}
+}
+
+// CanView reports whether domain may be viewed; edit grants imply view
+func (s *ZoneScope) CanView(domain string) bool {
+	if s == nil {
+		return false
+	}
+	return s.Unrestricted || matchesZone(s.ViewZones, domain) || matchesZone(s.EditZones, domain)
+}
+
+// CanEdit reports whether domain may be changed
+func (s *ZoneScope) CanEdit(domain string) bool {
+	if s == nil {
+		return false
+	}
+	return s.Unrestricted || matchesZone(s.EditZones, domain)
+}
+
+// GroupsFromHeaders parses the comma-separated group headers set by the reverse proxy
+func GroupsFromHeaders(header http.Header) []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, name := range groupHeaders {
+		for _, value := range header.Values(name) {
+			for _, group := range strings.Split(value, ",") {
+				group = strings.TrimSpace(group)
+				if group != "" && !seen[group] {
+					seen[group] = true
+					groups = append(groups, group)
+				}
+			}
+		}
+	}
+	return groups
+}
+
+// zoneGrantsStrict reports whether users without a matching grant are denied
+// every zone. ZONE_GRANTS_STRICT defaults to true; only an explicit false
+// opts into the lenient behaviour.
+func zoneGrantsStrict() bool {
+	value, ok := os.LookupEnv("ZONE_GRANTS_STRICT")
+	return !ok || strings.TrimSpace(value) == "" || IsTruthy(value)
+}
+
+// ResolveZoneScope builds the zone scope for a user. Admins are unrestricted.
+// Users whose groups match at least one zone grant are limited to the granted
+// zones; users without grants see nothing, unless ZONE_GRANTS_STRICT=false
+// gives them access to all zones.
+func ResolveZoneScope(db *gorm.DB, role string, groups []string) (*ZoneScope, error) {
+	if role == models.RoleAdmin {
+		return UnrestrictedScope(), nil
+	}
+
+	var grants []models.ZoneGrant
+	if len(groups) > 0 {
+		if err := db.Where("group_name IN ?", groups).Find(&grants).Error; err != nil {
+			return nil, fmt.Errorf("failed to load zone grants: %w", err)
+		}
+	}
+
+	if len(grants) == 0 {
+		if zoneGrantsStrict() {
+			return &ZoneScope{}, nil
+		}
+		log.Printf("Zone scope: %s user with groups %v matches no zone grant, allowing all zones because ZONE_GRANTS_STRICT=false", role, groups)
+		return UnrestrictedScope(), nil
+	}
+
+	scope := &ZoneScope{}
+	for _, grant := range grants {
+		zone := NormalizeDomain(grant.Zone)
+		if grant.Permission == models.ZonePermissionEdit {
+			scope.EditZones = append(scope.EditZones, zone)
+		} else {
+			scope.ViewZones = append(scope.ViewZones, zone)
+		}
+	}
+
+	return scope, nil
+}
+
+// NormalizeDomain lowercases a domain and strips its trailing dot
+func NormalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// DomainInZone reports whether domain equals zone or is below it; zone "*" matches everything
+func DomainInZone(domain, zone string) bool {
+	domain = NormalizeDomain(domain)
+	zone = NormalizeDomain(zone)
+	return zone == "*" || domain == zone || strings.HasSuffix(domain, "."+zone)
+}
+
+// matchesZone reports whether domain equals or is below one of the zones
+func matchesZone(zones []string, domain string) bool {
+	for _, zone := range zones {
+		if DomainInZone(domain, zone) {
+			return true
+		}
+	}
+	return false
+}
@@ -56,10 +56,30 @@ func Initialize() error {
 func migrate() error {
 	log.Println("Running database migrations...")
 
+	// Health check standbys reference records, not servers
+	if DB.Migrator().HasColumn(&models.HealthCheck{}, "standby_server_id") &&
+		!DB.Migrator().HasColumn(&models.HealthCheck{}, "standby_record_id") {
+		if err := DB.Migrator().RenameColumn(&models.HealthCheck{}, "standby_server_id", "standby_record_id"); err != nil {
+			return fmt.Errorf("failed to rename health_checks.standby_server_id: %w", err)
+		}
+	}
+
 	err := DB.AutoMigrate(
 		&models.Provider{},
+		&models.Server{},
 		&models.DNSRecord{},
 		&models.AuditLog{},
+		&models.AuditChainState{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.User{},
+		&models.ZoneGrant{},
+		&models.APIToken{},
+		&models.ChangeRequest{},
+		&models.ProtectedZone{},
+		&models.AcmeCredential{},
+		&models.DynDNSCredential{},
+		&models.HealthCheck{},
 	)
 
 	if err != nil {
@@ -0,0 +1,261 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Event types emitted by the handlers
+const (
+	RecordCreated     = "record.created"
+	RecordUpdated     = "record.updated"
+	RecordDeleted     = "record.deleted"
+	RecordHidden      = "record.hidden"
+	RecordEnabled     = "record.enabled"
+	RecordDisabled    = "record.disabled"
+	RecordPropagation = "record.propagation"
+	ProviderCreated   = "provider.created"
+	ProviderUpdated   = "provider.updated"
+	ProviderDeleted   = "provider.deleted"
+	ProviderSynced    = "provider.synced"
+	DriftDetected     = "drift.detected"
+	ChangeRequested   = "change.requested"
+	ChangeApplied     = "change.applied"
+	ChangeRejected    = "change.rejected"
+	ChangeCancelled   = "change.cancelled"
+	ServerFailover    = "server.failover"
+	ServerRecovered   = "server.recovered"
+	DanglingDetected  = "dangling.detected"
+)
+
+// Types lists every event type that can be subscribed to
+var Types = []string{
+	RecordCreated, RecordUpdated, RecordDeleted, RecordHidden, RecordEnabled, RecordDisabled, RecordPropagation,
+	ProviderCreated, ProviderUpdated, ProviderDeleted, ProviderSynced, DriftDetected,
+	ChangeRequested, ChangeApplied, ChangeRejected, ChangeCancelled, ServerFailover, ServerRecovered, DanglingDetected,
+}
+
+// Event is a typed change notification
+type Event struct {
+	ID        uint64      `json:"id"`
+	Type      string      `json:"type"`
+	Actor     string      `json:"actor,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// historySize is the number of recent events kept for resuming subscribers
+const historySize = 1000
+
+// subscriber is a registered event receiver
+type subscriber struct {
+	ch chan Event
+	// closeOnOverflow closes the channel instead of dropping events, so
+	// resumable subscribers reconnect and replay from history
+	closeOnOverflow bool
+	// blockTimeout is how long Publish waits for a full buffer before
+	// dropping the event; zero drops immediately
+	blockTimeout time.Duration
+	closed       bool
+}
+
+// Bus fans out published events to all subscribers
+type Bus struct {
+	epoch       int64 // distinguishes event IDs issued by different process runs
+	mu          sync.Mutex
+	nextID      uint64
+	nextSubID   int
+	subscribers map[int]*subscriber
+	history     []Event
+	dropped     uint64
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{
+		epoch:       time.Now().UnixNano(),
+		subscribers: make(map[int]*subscriber),
+	}
+}
+
+// Epoch identifies this bus instance; event IDs are only comparable within one epoch
+func (b *Bus) Epoch() int64 {
+	return b.epoch
+}
+
+// DefaultBus is the process-wide bus used by the handlers
+var DefaultBus = NewBus()
+
+// Publish emits an event on the default bus
+func Publish(eventType, actor string, data interface{}) Event {
+	return DefaultBus.Publish(eventType, actor, data)
+}
+
+// Subscribe registers a subscriber on the default bus
+func Subscribe(buffer int) (<-chan Event, func()) {
+	return DefaultBus.Subscribe(buffer)
+}
+
+// SubscribeReliable registers a blocking subscriber on the default bus
+func SubscribeReliable(buffer int, timeout time.Duration) (<-chan Event, func()) {
+	return DefaultBus.SubscribeReliable(buffer, timeout)
+}
+
+// Dropped returns the number of events the default bus failed to deliver
+func Dropped() uint64 {
+	return DefaultBus.Dropped()
+}
+
+// SubscribeSince registers a resumable subscriber on the default bus
+func SubscribeSince(lastID uint64, buffer int) ([]Event, bool, <-chan Event, func()) {
+	return DefaultBus.SubscribeSince(lastID, buffer)
+}
+
+// IsValidType reports whether eventType is a known event type
+func IsValidType(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish assigns the event an ID and delivers it to every subscriber.
+// Subscribers whose buffer is full miss the event, except reliable ones,
+// which Publish waits on for up to their timeout first.
+func (b *Bus) Publish(eventType, actor string, data interface{}) Event {
+	// Hold the lock for the whole fan-out so subscribers see events in ID order
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := Event{
+		ID:        b.nextID,
+		Type:      eventType,
+		Actor:     actor,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+
+	b.history = append(b.history, event)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for id, sub := range b.subscribers {
+		if sub.closed {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+			continue
+		default:
+		}
+
+		if sub.closeOnOverflow {
+			log.Printf("Events: Subscriber %d fell behind, disconnecting it", id)
+			sub.closed = true
+			close(sub.ch)
+			continue
+		}
+		if sub.blockTimeout > 0 {
+			timer := time.NewTimer(sub.blockTimeout)
+			select {
+			case sub.ch <- event:
+				timer.Stop()
+				continue
+			case <-timer.C:
+			}
+		}
+		b.dropped++
+		log.Printf("Events: Subscriber %d is full, dropping event %d (%s); %d dropped so far",
+			id, event.ID, event.Type, b.dropped)
+	}
+
+	return event
+}
+
+// Subscribe returns a channel receiving every published event and a function
+// that unsubscribes and closes the channel
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.addSubscriber(buffer, false, 0)
+}
+
+// SubscribeReliable is like Subscribe, but when the channel is full Publish
+// waits up to timeout for room before dropping the event. Meant for
+// subscribers that persist events, so a slow database does not lose them.
+func (b *Bus) SubscribeReliable(buffer int, timeout time.Duration) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.addSubscriber(buffer, false, timeout)
+}
+
+// Dropped returns the number of events not delivered to a non-resumable
+// subscriber because its buffer stayed full
+func (b *Bus) Dropped() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.dropped
+}
+
+// SubscribeSince returns the events published after lastID followed by a live
+// subscription, with no gap between the two. complete is false when events
+// after lastID are no longer retained (or lastID comes from before a restart),
+// in which case the subscriber should reload its full state.
+// A resumable subscriber that falls behind has its channel closed.
+func (b *Bus) SubscribeSince(lastID uint64, buffer int) (replay []Event, complete bool, ch <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if lastID > 0 {
+		switch {
+		case lastID > b.nextID:
+			complete = false
+		case len(b.history) > 0 && b.history[0].ID > lastID+1:
+			complete = false
+		}
+
+		for _, event := range b.history {
+			if event.ID > lastID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch, cancel = b.addSubscriber(buffer, true, 0)
+	return replay, complete, ch, cancel
+}
+
+// addSubscriber registers a new subscriber; the caller must hold b.mu
+func (b *Bus) addSubscriber(buffer int, closeOnOverflow bool, blockTimeout time.Duration) (<-chan Event, func()) {
+	sub := &subscriber{
+		ch:              make(chan Event, buffer),
+		closeOnOverflow: closeOnOverflow,
+		blockTimeout:    blockTimeout,
+	}
+
+	b.nextSubID++
+	id := b.nextSubID
+	b.subscribers[id] = sub
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers, id)
+			if !sub.closed {
+				sub.closed = true
+				close(sub.ch)
+			}
+		})
+	}
+}
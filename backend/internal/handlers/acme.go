@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/services"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// acmeChallengePrefix is the label ACME DNS-01 challenges are published under
+const acmeChallengePrefix = "_acme-challenge."
+
+// credentialUsernamePattern restricts ACME and DynDNS usernames to what basic auth carries safely
+var credentialUsernamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// AcmeCredentialRequest represents the request to create an ACME credential
+type AcmeCredentialRequest struct {
+	Username string   `json:"username" binding:"required"`
+	Domains  []string `json:"domains" binding:"required"` // each domain also covers its subdomains
+}
+
+// AcmeChallengeRequest is the body sent by lego's httpreq provider. The default
+// mode sends fqdn and value; RAW mode sends domain, token and keyAuth instead.
+type AcmeChallengeRequest struct {
+	FQDN    string `json:"fqdn"`
+	Value   string `json:"value"`
+	Domain  string `json:"domain"`
+	Token   string `json:"token"`
+	KeyAuth string `json:"keyAuth"`
+}
+
+// GetAcmeCredentials returns all ACME credentials (without secrets)
+func GetAcmeCredentials(c *gin.Context) {
+	var credentials []models.AcmeCredential
+	if err := database.DB.Order("username ASC").Find(&credentials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ACME credentials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// CreateAcmeCredential issues basic auth credentials for the ACME challenge
+// API. The password is only returned in this response.
+func CreateAcmeCredential(c *gin.Context) {
+	var req AcmeCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if !credentialUsernamePattern.MatchString(username) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username may only contain letters, digits, '.', '_' and '-'"})
+		return
+	}
+
+	domains := make([]string, 0, len(req.Domains))
+	for _, domain := range req.Domains {
+		domain = auth.NormalizeDomain(strings.TrimPrefix(strings.TrimSpace(domain), "*."))
+		if domain == "" || domain == "*" {
+			continue
+		}
+		domains = append(domains, domain)
+	}
+	if len(domains) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one domain is required"})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.AcmeCredential{}).Where("username = ?", username).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "ACME credential " + username + " already exists"})
+		return
+	}
+
+	secret, hash, err := auth.GenerateCredentialSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate credential"})
+		return
+	}
+
+	credential := models.AcmeCredential{
+		Username:   username,
+		SecretHash: hash,
+		Domains:    strings.Join(domains, ","),
+		CreatedBy:  c.GetString(auth.ContextUsername),
+	}
+
+	if err := database.DB.Create(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save ACME credential"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeACME, credential.ID, gin.H{
+		"username": credential.Username,
+		"domains":  domains,
+	}, nil, credential)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "ACME credential created successfully, store the password now as it will not be shown again",
+		"password":   secret,
+		"credential": credential,
+	})
+}
+
+// DeleteAcmeCredential revokes an ACME credential
+func DeleteAcmeCredential(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ACME credential ID"})
+		return
+	}
+
+	var credential models.AcmeCredential
+	if err := database.DB.First(&credential, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ACME credential not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ACME credential"})
+		return
+	}
+
+	logAuditChange(c, models.ActionDelete, models.ResourceTypeACME, credential.ID, gin.H{
+		"username": credential.Username,
+		"domains":  credential.Domains,
+	}, credential, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "ACME credential deleted successfully"})
+}
+
+// AcmePresent publishes a DNS-01 challenge TXT record (lego httpreq "/present")
+func AcmePresent(c *gin.Context) {
+	credential, fqdn, value, ok := acmeChallenge(c)
+	if !ok {
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.DNSRecord{}).
+		Where("full_domain = ? AND record_type = ? AND target_value = ? AND managed = ?", fqdn, models.RecordTypeTXT, value, true).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Challenge record already present"})
+		return
+	}
+
+	// Challenge records clean themselves up through the reaper should the
+	// client never call cleanup. They are not subject to four-eyes approval:
+	// the credential can only ever touch _acme-challenge names.
+	expiresAt := time.Now().Add(acmeChallengeLifetime())
+	record, err := createRecordOp(acmeActor(c, credential), CreateRecordRequest{
+		FullDomain:  fqdn,
+		RecordType:  models.RecordTypeTXT,
+		TargetValue: value,
+		TTL:         acmeRecordTTL(fqdn),
+		Notes:       "ACME DNS-01 challenge (" + credential.Username + ")",
+		ExpiresAt:   &expiresAt,
+	}, gin.H{"acme_credential": credential.Username})
+	if err != nil {
+		log.Printf("AcmePresent: Failed to create challenge for %s (%s): %v", fqdn, credential.Username, err)
+		respondRecordOpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Challenge record created",
+		"record":  record,
+	})
+}
+
+// AcmeCleanup removes a DNS-01 challenge TXT record (lego httpreq "/cleanup")
+func AcmeCleanup(c *gin.Context) {
+	credential, fqdn, value, ok := acmeChallenge(c)
+	if !ok {
+		return
+	}
+
+	var records []models.DNSRecord
+	if err := database.DB.
+		Where("full_domain = ? AND record_type = ? AND target_value = ? AND managed = ?", fqdn, models.RecordTypeTXT, value, true).
+		Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load challenge records"})
+		return
+	}
+
+	actor := acmeActor(c, credential)
+	for i := range records {
+		if err := deleteRecordOp(actor, &records[i], gin.H{"acme_credential": credential.Username}); err != nil {
+			log.Printf("AcmeCleanup: Failed to delete challenge %s (%s): %v", fqdn, credential.Username, err)
+			respondRecordOpError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Challenge record removed",
+		"deleted": len(records),
+	})
+}
+
+// acmeChallenge authenticates the ACME client and returns the challenge name
+// and value, writing an error response and returning false when it cannot
+func acmeChallenge(c *gin.Context) (*models.AcmeCredential, string, string, bool) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		c.Header("WWW-Authenticate", `Basic realm="dnsmesh-acme"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return nil, "", "", false
+	}
+
+	credential, err := auth.AuthenticateACMECredential(database.DB, username, password)
+	if err != nil {
+		if !errors.Is(err, auth.ErrACMECredentialInvalid) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, "", "", false
+		}
+		log.Printf("ACME: Rejected credential '%s' from %s", username, c.ClientIP())
+		c.Header("WWW-Authenticate", `Basic realm="dnsmesh-acme"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, "", "", false
+	}
+
+	var req AcmeChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, "", "", false
+	}
+
+	fqdn, value := auth.NormalizeDomain(req.FQDN), req.Value
+	if req.KeyAuth != "" {
+		// RAW mode: the client leaves computing the TXT value to us
+		fqdn = acmeChallengePrefix + auth.NormalizeDomain(strings.TrimPrefix(req.Domain, "*."))
+		sum := sha256.Sum256([]byte(req.KeyAuth))
+		value = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+
+	domain, found := strings.CutPrefix(fqdn, acmeChallengePrefix)
+	if !found || domain == "" || value == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected an _acme-challenge fqdn and a value"})
+		return nil, "", "", false
+	}
+
+	if !auth.ACMECredentialAllows(credential, domain) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Credential '" + credential.Username + "' may not answer challenges for " + domain})
+		return nil, "", "", false
+	}
+
+	return credential, fqdn, value, true
+}
+
+// acmeActor returns the actor recorded for changes made with an ACME credential
+func acmeActor(c *gin.Context, credential *models.AcmeCredential) auditActor {
+	return auditActor{
+		Username:   "acme:" + credential.Username,
+		AuthMethod: auth.MethodACME,
+		IPAddress:  c.ClientIP(),
+	}
+}
+
+// acmeChallengeLifetime is how long an uncleaned challenge record survives,
+// from ACME_CHALLENGE_TTL (default 1h)
+func acmeChallengeLifetime() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("ACME_CHALLENGE_TTL")); err == nil && value > 0 {
+		return value
+	}
+	return time.Hour
+}
+
+// acmeRecordTTL is the TTL of a challenge record: short, but not below the
+// minimum of the zone's provider
+func acmeRecordTTL(fqdn string) int {
+	ttl := 120
+	zoneRecord, err := findZoneForDomain(fqdn)
+	if err != nil {
+		return ttl
+	}
+	var provider models.Provider
+	if err := database.DB.First(&provider, zoneRecord.ProviderID).Error; err != nil {
+		return ttl
+	}
+	return max(ttl, services.GetProviderCapabilities(provider).MinTTL)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTokenLifetimeDays applies when a token request does not set expires_in_days
+const defaultTokenLifetimeDays = 90
+
+// CreateAPITokenRequest represents the request to issue an API token
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Owner         string   `json:"owner"`           // defaults to the caller; other owners require admin
+	Scopes        []string `json:"scopes"`          // permissions, defaults to read
+	Zones         []string `json:"zones"`           // zone suffixes, empty = owner's zones
+	ExpiresInDays *int     `json:"expires_in_days"` // 0 = never expires
+}
+
+// requireInteractiveUser rejects token-authenticated requests so a leaked token
+// cannot mint or revoke other tokens
+func requireInteractiveUser(c *gin.Context) bool {
+	if c.GetString(auth.ContextAuthMethod) == auth.MethodAPIToken {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot manage API tokens"})
+		return false
+	}
+	return true
+}
+
+// GetAPITokens returns the caller's tokens, or every token for admins
+func GetAPITokens(c *gin.Context) {
+	query := database.DB.Order("id DESC")
+	if !auth.RoleHasPermission(c.GetString(auth.ContextRole), auth.PermAdmin) {
+		query = query.Where("owner = ?", c.GetString(auth.ContextUsername))
+	}
+
+	var tokens []models.APIToken
+	if err := query.Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// CreateAPIToken issues a scoped API token. The secret token is only returned in this response.
+func CreateAPIToken(c *gin.Context) {
+	if !requireInteractiveUser(c) {
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token name is required"})
+		return
+	}
+
+	caller := c.GetString(auth.ContextUsername)
+	owner := strings.TrimSpace(req.Owner)
+	if owner == "" {
+		owner = caller
+	}
+	if owner != caller && !auth.RoleHasPermission(c.GetString(auth.ContextRole), auth.PermAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can create tokens for other users"})
+		return
+	}
+
+	// Service accounts are provisioned like any first login and can be promoted afterwards
+	ownerUser, err := auth.EnsureUser(database.DB, owner)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load token owner"})
+		return
+	}
+
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{auth.PermRead}
+	}
+	for _, scope := range req.Scopes {
+		if !auth.IsValidPermission(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope: " + scope})
+			return
+		}
+		if !auth.RoleHasPermission(ownerUser.Role, scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Owner role '" + ownerUser.Role + "' does not grant scope: " + scope})
+			return
+		}
+	}
+
+	// Tokens may only cover zones both the caller and the owner can edit
+	ownerScope, err := auth.OwnerZoneScope(database.DB, ownerUser)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load owner zone permissions"})
+		return
+	}
+	scope := zoneScope(c)
+	zones := make([]string, 0, len(req.Zones))
+	for _, zone := range req.Zones {
+		zone = auth.NormalizeDomain(zone)
+		if zone == "" {
+			continue
+		}
+		if !scope.CanEdit(zone) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No edit permission for zone " + zone})
+			return
+		}
+		if !ownerScope.CanEdit(zone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Owner '" + ownerUser.Username + "' has no edit permission for zone " + zone})
+			return
+		}
+		zones = append(zones, zone)
+	}
+	if !scope.Unrestricted && len(zones) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Zone-restricted users must limit tokens to specific zones"})
+		return
+	}
+
+	lifetime := defaultTokenLifetimeDays
+	if req.ExpiresInDays != nil {
+		lifetime = *req.ExpiresInDays
+	}
+	if lifetime < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must not be negative"})
+		return
+	}
+
+	token, hash, prefix, err := auth.GenerateAPIToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	apiToken := models.APIToken{
+		Name:      req.Name,
+		Owner:     ownerUser.Username,
+		TokenHash: hash,
+		Prefix:    prefix,
+		Scopes:    strings.Join(req.Scopes, ","),
+		Zones:     strings.Join(zones, ","),
+		CreatedBy: caller,
+	}
+	if lifetime > 0 {
+		expiresAt := time.Now().AddDate(0, 0, lifetime)
+		apiToken.ExpiresAt = &expiresAt
+	}
+
+	if err := database.DB.Create(&apiToken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save API token"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeAPIToken, apiToken.ID, gin.H{
+		"name":   apiToken.Name,
+		"owner":  apiToken.Owner,
+		"scopes": req.Scopes,
+		"zones":  zones,
+	}, nil, apiToken)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "API token created successfully, store it now as it will not be shown again",
+		"token":     token,
+		"api_token": apiToken,
+	})
+}
+
+// RevokeAPIToken revokes a token; owners may revoke their own, admins any
+func RevokeAPIToken(c *gin.Context) {
+	if !requireInteractiveUser(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	var apiToken models.APIToken
+	if err := database.DB.First(&apiToken, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+
+	if apiToken.Owner != c.GetString(auth.ContextUsername) &&
+		!auth.RoleHasPermission(c.GetString(auth.ContextRole), auth.PermAdmin) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+
+	if apiToken.RevokedAt != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "API token already revoked",
+			"api_token": apiToken,
+		})
+		return
+	}
+
+	before := apiToken
+	now := time.Now()
+	apiToken.RevokedAt = &now
+	if err := database.DB.Save(&apiToken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+		return
+	}
+
+	logAuditChange(c, models.ActionDelete, models.ResourceTypeAPIToken, apiToken.ID, gin.H{
+		"name":   apiToken.Name,
+		"owner":  apiToken.Owner,
+		"action": "revoke",
+	}, before, apiToken)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "API token revoked successfully",
+		"api_token": apiToken,
+	})
+}
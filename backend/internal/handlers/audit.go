@@ -1,30 +1,58 @@
 package handlers
 
 import (
+	"dnsmesh/internal/audit"
+	"dnsmesh/internal/auth"
 	"dnsmesh/internal/database"
 	"dnsmesh/internal/models"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// GetAuditLogs returns audit logs with pagination
+// auditExportBatchSize is the number of rows fetched per query while exporting
+const auditExportBatchSize = 500
+
+// requireAuditAccess rejects zone-restricted callers, since audit entries of
+// every zone carry domains, targets and before/after diffs
+func requireAuditAccess(c *gin.Context) bool {
+	if !zoneScope(c).Unrestricted {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Zone-restricted users cannot read the audit log"})
+		return false
+	}
+	return true
+}
+
+// GetAuditLogs returns audit logs with cursor-based pagination.
+// Pass the returned next_cursor as the cursor parameter to fetch the next page.
 func GetAuditLogs(c *gin.Context) {
+	if !requireAuditAccess(c) {
+		return
+	}
+
 	var logs []models.AuditLog
 
 	// Build query
-	query := database.DB.Order("created_at DESC")
-
-	// Filter by resource type
-	if resourceType := c.Query("resource_type"); resourceType != "" {
-		query = query.Where("resource_type = ?", resourceType)
+	query, err := filterAuditLogs(c, database.DB.Model(&models.AuditLog{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Filter by action
-	if action := c.Query("action"); action != "" {
-		query = query.Where("action = ?", action)
+	// Count all matching rows before pagination is applied
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count audit logs"})
+		return
 	}
 
 	// Pagination
@@ -35,38 +63,350 @@ func GetAuditLogs(c *gin.Context) {
 		}
 	}
 
-	offset := 0
-	if offsetStr := c.Query("offset"); offsetStr != "" {
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := strconv.ParseUint(cursorStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = query.Where("id < ?", cursor)
+	} else if offsetStr := c.Query("offset"); offsetStr != "" {
+		// Offset pagination is kept for older clients
 		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+			query = query.Offset(parsedOffset)
 		}
 	}
 
-	query = query.Limit(limit).Offset(offset)
-
-	// Fetch logs
-	if err := query.Find(&logs).Error; err != nil {
+	// Fetch one extra row to learn whether another page exists
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&logs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
 		return
 	}
 
+	hasMore := len(logs) > limit
+	var nextCursor *uint
+	if hasMore {
+		logs = logs[:limit]
+		nextCursor = &logs[len(logs)-1].ID
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"logs":  logs,
-		"count": len(logs),
+		"logs":        logs,
+		"count":       len(logs),
+		"total":       total,
+		"has_more":    hasMore,
+		"next_cursor": nextCursor,
 	})
 }
 
+// ExportAuditLogs streams all matching audit logs, oldest first, as CSV or JSONL
+func ExportAuditLogs(c *gin.Context) {
+	if !requireAuditAccess(c) {
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected csv or jsonl"})
+		return
+	}
+
+	query, err := filterAuditLogs(c, database.DB.Model(&models.AuditLog{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("audit-logs-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Status(http.StatusOK)
+
+	var csvWriter *csv.Writer
+	jsonEncoder := json.NewEncoder(c.Writer)
+	if format == "csv" {
+		csvWriter = csv.NewWriter(c.Writer)
+		csvWriter.Write([]string{
+			"id", "created_at", "action", "resource_type", "resource_id", "actor",
+			"auth_method", "token_name", "ip_address", "batch_id", "details", "changes", "prev_hash", "hash",
+		})
+	}
+
+	var lastID uint
+	exported := 0
+	for {
+		var batch []models.AuditLog
+		if err := query.Session(&gorm.Session{}).
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(auditExportBatchSize).
+			Find(&batch).Error; err != nil {
+			// Headers are already sent, so the truncated body is all we can signal
+			log.Printf("ExportAuditLogs: Failed to fetch batch after id %d: %v", lastID, err)
+			break
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, entry := range batch {
+			if csvWriter != nil {
+				csvWriter.Write([]string{
+					strconv.FormatUint(uint64(entry.ID), 10),
+					entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+					entry.Action,
+					entry.ResourceType,
+					strconv.FormatUint(uint64(entry.ResourceID), 10),
+					entry.Actor,
+					entry.AuthMethod,
+					entry.TokenName,
+					entry.IPAddress,
+					entry.BatchID,
+					entry.Details,
+					entry.Changes,
+					entry.PrevHash,
+					entry.Hash,
+				})
+			} else {
+				jsonEncoder.Encode(entry)
+			}
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+		}
+		c.Writer.Flush()
+
+		exported += len(batch)
+		lastID = batch[len(batch)-1].ID
+	}
+
+	log.Printf("ExportAuditLogs: Exported %d audit logs as %s", exported, format)
+}
+
+// filterAuditLogs applies the audit log search parameters shared by listing and export
+func filterAuditLogs(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	// Filter by resource type
+	if resourceType := c.Query("resource_type"); resourceType != "" {
+		query = query.Where("resource_type = ?", resourceType)
+	}
+
+	// Filter by resource ID
+	if resourceIDStr := c.Query("resource_id"); resourceIDStr != "" {
+		resourceID, err := strconv.ParseUint(resourceIDStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource_id")
+		}
+		query = query.Where("resource_id = ?", resourceID)
+	}
+
+	// Filter by action
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	// Filter by actor
+	if actor := c.Query("actor"); actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+
+	// Filter by authentication method
+	if authMethod := c.Query("auth_method"); authMethod != "" {
+		query = query.Where("auth_method = ?", authMethod)
+	}
+
+	// Filter by API token
+	if tokenID := c.Query("token_id"); tokenID != "" {
+		query = query.Where("token_id = ?", tokenID)
+	}
+
+	// Filter by batch operation
+	if batchID := c.Query("batch_id"); batchID != "" {
+		query = query.Where("batch_id = ?", batchID)
+	}
+
+	// Substring search for a domain inside the JSON details
+	if domain := strings.TrimSpace(c.Query("domain")); domain != "" {
+		query = query.Where("details LIKE ? ESCAPE '\\'", "%"+escapeLike(domain)+"%")
+	}
+
+	// Time range filters (inclusive since, exclusive until)
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := parseAuditTime(sinceStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+		query = query.Where("created_at >= ?", since)
+	}
+
+	if untilStr := c.Query("until"); untilStr != "" {
+		until, err := parseAuditTime(untilStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid until: %w", err)
+		}
+		query = query.Where("created_at < ?", until)
+	}
+
+	return query, nil
+}
+
+// parseAuditTime accepts RFC 3339 timestamps or plain dates (YYYY-MM-DD, UTC)
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 timestamp or YYYY-MM-DD date")
+	}
+	return t, nil
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+	return replacer.Replace(value)
+}
+
+// VerifyAuditLogs walks the audit hash chain and reports the first broken link
+func VerifyAuditLogs(c *gin.Context) {
+	result, err := audit.Verify(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit logs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// auditChange captures a single field's value before and after a change
+type auditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// auditIgnoredFields are bookkeeping fields left out of before/after diffs
+var auditIgnoredFields = map[string]bool{
+	"created_at":  true,
+	"updated_at":  true,
+	"provider":    true,
+	"dns_records": true,
+}
+
+// auditActor identifies who made a change, for audit entries and events.
+// Requests use requestActor; background jobs use systemActor.
+type auditActor struct {
+	Username   string
+	AuthMethod string
+	IPAddress  string
+	TokenID    uint
+	TokenName  string
+	BatchID    string // set while executing a batch of record operations
+}
+
+// requestActor returns the authenticated caller of a request
+func requestActor(c *gin.Context) auditActor {
+	return auditActor{
+		Username:   c.GetString(auth.ContextUsername),
+		AuthMethod: c.GetString(auth.ContextAuthMethod),
+		IPAddress:  c.ClientIP(),
+		TokenID:    c.GetUint(auth.ContextTokenID),
+		TokenName:  c.GetString(auth.ContextTokenName),
+	}
+}
+
+// systemActor returns the actor recorded for a background job
+func systemActor(job string) auditActor {
+	return auditActor{Username: "system:" + job, AuthMethod: auth.MethodSystem}
+}
+
 // logAudit helper function to log audit events
 func logAudit(c *gin.Context, action, resourceType string, resourceID uint, details gin.H) {
+	logAuditChange(c, action, resourceType, resourceID, details, nil, nil)
+}
+
+// logAuditChange logs an audit event together with a field-level diff of the
+// resource state before and after the change. Either snapshot may be nil for
+// creations and deletions.
+func logAuditChange(c *gin.Context, action, resourceType string, resourceID uint, details gin.H, before, after interface{}) {
+	logActorAuditChange(requestActor(c), action, resourceType, resourceID, details, before, after)
+}
+
+// logActorAuditChange is logAuditChange for an explicit actor
+func logActorAuditChange(actor auditActor, action, resourceType string, resourceID uint, details gin.H, before, after interface{}) {
 	detailsJSON, _ := json.Marshal(details)
 
-	log := models.AuditLog{
+	var changesJSON []byte
+	if changes := diffAuditSnapshots(before, after); len(changes) > 0 {
+		changesJSON, _ = json.Marshal(changes)
+	}
+
+	entry := models.AuditLog{
 		Action:       action,
 		ResourceType: resourceType,
 		ResourceID:   resourceID,
 		Details:      string(detailsJSON),
-		IPAddress:    c.ClientIP(),
+		Changes:      string(changesJSON),
+		Actor:        actor.Username,
+		AuthMethod:   actor.AuthMethod,
+		IPAddress:    actor.IPAddress,
+		TokenID:      actor.TokenID,
+		TokenName:    actor.TokenName,
+		BatchID:      actor.BatchID,
+	}
+
+	if err := audit.Append(database.DB, &entry); err != nil {
+		log.Printf("logAudit: Failed to write audit entry (%s %s %d): %v", action, resourceType, resourceID, err)
+	}
+}
+
+// diffAuditSnapshots compares the JSON representation of two resource
+// snapshots and returns the fields whose values differ
+func diffAuditSnapshots(before, after interface{}) map[string]auditChange {
+	beforeFields := auditSnapshot(before)
+	afterFields := auditSnapshot(after)
+
+	changes := make(map[string]auditChange)
+	for key, beforeValue := range beforeFields {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		afterValue, ok := afterFields[key]
+		if !ok || !reflect.DeepEqual(beforeValue, afterValue) {
+			changes[key] = auditChange{Before: beforeValue, After: afterValue}
+		}
+	}
+	for key, afterValue := range afterFields {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if _, ok := beforeFields[key]; !ok {
+			changes[key] = auditChange{After: afterValue}
+		}
+	}
+
+	return changes
+}
+
+// auditSnapshot flattens a resource into its JSON field map
+func auditSnapshot(resource interface{}) map[string]interface{} {
+	if resource == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
 	}
 
-	database.DB.Create(&log)
+	return fields
 }
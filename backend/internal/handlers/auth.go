@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"log"
 	"net/http"
 
 	"dnsmesh/internal/auth"
@@ -9,45 +8,36 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// GetCurrentUser returns the current authenticated user from Remote-User header
+// GetCurrentUser returns the current authenticated user and their role
 func GetCurrentUser(c *gin.Context) {
-	if username, ok := auth.BypassUser(); ok {
-		c.JSON(http.StatusOK, gin.H{
-			"user": gin.H{
-				"username": username,
-			},
-		})
-		return
+	role := c.GetString(auth.ContextRole)
+
+	user := gin.H{
+		"username":    c.GetString(auth.ContextUsername),
+		"auth_method": c.GetString(auth.ContextAuthMethod),
+		"role":        role,
+		"permissions": auth.RolePermissions(role),
+		"groups":      c.GetStringSlice(auth.ContextGroups),
+		"zone_scope":  zoneScope(c),
 	}
 
-	// Try multiple common header names for reverse proxy authentication
-	username := c.GetHeader("Remote-User")
-	if username == "" {
-		username = c.GetHeader("remote-user")
-	}
-	if username == "" {
-		username = c.GetHeader("X-Remote-User")
-	}
-	if username == "" {
-		username = c.GetHeader("X-Forwarded-User")
+	// Claims from the native OIDC login
+	if claims, ok := c.Get(auth.ContextClaims); ok {
+		user["claims"] = claims
 	}
 
-	// Log for debugging
-	log.Printf("GetCurrentUser - Remote-User: '%s', remote-user: '%s', X-Remote-User: '%s', X-Forwarded-User: '%s', Final: '%s'",
-		c.GetHeader("Remote-User"),
-		c.GetHeader("remote-user"),
-		c.GetHeader("X-Remote-User"),
-		c.GetHeader("X-Forwarded-User"),
-		username)
-
-	if username == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated: Remote-User header not found"})
-		return
+	// API tokens only hold the permissions both their scopes and the owner's role grant
+	if scopes, ok := c.Get(auth.ContextScopes); ok {
+		var permissions []string
+		for _, permission := range auth.RolePermissions(role) {
+			if auth.ScopesAllow(scopes.([]string), permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+		user["permissions"] = permissions
+		user["token_id"] = c.GetUint(auth.ContextTokenID)
+		user["token_name"] = c.GetString(auth.ContextTokenName)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"username": username,
-		},
-	})
+	c.JSON(http.StatusOK, gin.H{"user": user})
 }
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"encoding/hex"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batches apply several record operations as one audited unit. Operations
+// run in order; when one fails, those already applied are undone in reverse
+// order with compensating provider calls, since providers have no
+// transactions. Every audit entry of a batch carries its batch ID.
+
+// batchOpHide removes a record from management; the other batch operations
+// are the models.ChangeOp* constants
+const batchOpHide = "hide"
+
+// Batch and batch operation statuses
+const (
+	batchStatusPlanned        = "planned"
+	batchStatusApplied        = "applied"
+	batchStatusFailed         = "failed"
+	batchStatusSkipped        = "skipped"
+	batchStatusRolledBack     = "rolled_back"
+	batchStatusRollbackFailed = "rollback_failed"
+)
+
+// batchOp is one record operation of a batch
+type batchOp struct {
+	Op          string              // models.ChangeOp* or batchOpHide
+	Record      *models.DNSRecord   // record to change, updated in place when applied; for creates the new record
+	Request     CreateRecordRequest // desired record for creates and updates
+	AllowServer bool                // deletes may remove server records
+	before      models.DNSRecord    // record before the operation, for rollback
+}
+
+// BatchOpResult is the outcome of one operation of a batch
+type BatchOpResult struct {
+	Index      int    `json:"index"`
+	Op         string `json:"op"`
+	RecordID   uint   `json:"record_id,omitempty"`
+	Domain     string `json:"domain"`
+	RecordType string `json:"record_type"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BatchResult is the outcome of a batch
+type BatchResult struct {
+	BatchID string          `json:"batch_id,omitempty"`
+	Status  string          `json:"status"` // planned, applied, rolled_back or rollback_failed
+	Results []BatchOpResult `json:"results"`
+}
+
+// newBatchID returns a random identifier for a batch
+func newBatchID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// planBatch describes ops without applying them
+func planBatch(ops []batchOp) BatchResult {
+	result := BatchResult{Status: batchStatusPlanned, Results: make([]BatchOpResult, 0, len(ops))}
+	for i, op := range ops {
+		opResult := BatchOpResult{
+			Index:      i,
+			Op:         op.Op,
+			RecordID:   op.Record.ID,
+			Domain:     op.Record.FullDomain,
+			RecordType: op.Record.RecordType,
+			From:       op.Record.TargetValue,
+			Status:     batchStatusPlanned,
+		}
+		if op.Op == models.ChangeOpCreate || op.Op == models.ChangeOpUpdate {
+			opResult.To = op.Request.TargetValue
+		}
+		result.Results = append(result.Results, opResult)
+	}
+	return result
+}
+
+// batchApprovalReason returns why any operation of a batch needs approval.
+// A batch cannot be queued as a change request, so callers refuse it instead.
+// Hiding never needs approval, as with single records.
+func batchApprovalReason(ops []batchOp) (string, error) {
+	var domains []string
+	isServer := false
+	for _, op := range ops {
+		if op.Op == batchOpHide {
+			continue
+		}
+		domains = append(domains, op.Record.FullDomain)
+		if op.Op == models.ChangeOpCreate || op.Op == models.ChangeOpUpdate {
+			domains = append(domains, op.Request.FullDomain)
+			isServer = isServer || op.Request.IsServer
+		}
+		isServer = isServer || op.Record.IsServer
+	}
+	return approvalReason(domains, isServer)
+}
+
+// runBatch applies ops in order as actor, rolling back the applied ones when
+// an operation fails
+func runBatch(actor auditActor, ops []batchOp, extra gin.H) BatchResult {
+	actor.BatchID = newBatchID()
+	result := planBatch(ops)
+	result.BatchID = actor.BatchID
+
+	for i := range ops {
+		if err := applyBatchOp(actor, &ops[i], extra); err != nil {
+			log.Printf("Batch %s: Operation %d (%s %s) failed: %v", actor.BatchID, i, ops[i].Op, ops[i].Record.FullDomain, err)
+			result.Results[i].Status = batchStatusFailed
+			result.Results[i].Error = err.Error()
+			for j := i + 1; j < len(ops); j++ {
+				result.Results[j].Status = batchStatusSkipped
+			}
+
+			result.Status = batchStatusRolledBack
+			for j := i - 1; j >= 0; j-- {
+				if err := undoBatchOp(actor, &ops[j], mergeDetails(gin.H{"rollback": true}, extra)); err != nil {
+					log.Printf("Batch %s: Rollback of operation %d (%s) failed: %v", actor.BatchID, j, ops[j].Record.FullDomain, err)
+					result.Results[j].Status = batchStatusRollbackFailed
+					result.Results[j].Error = err.Error()
+					result.Status = batchStatusRollbackFailed
+					continue
+				}
+				result.Results[j].Status = batchStatusRolledBack
+				result.Results[j].RecordID = ops[j].Record.ID
+			}
+			return result
+		}
+		result.Results[i].Status = batchStatusApplied
+		result.Results[i].RecordID = ops[i].Record.ID
+	}
+
+	result.Status = batchStatusApplied
+	return result
+}
+
+// applyBatchOp performs op, remembering the record state needed to undo it
+func applyBatchOp(actor auditActor, op *batchOp, extra gin.H) error {
+	op.before = *op.Record
+	switch op.Op {
+	case models.ChangeOpCreate:
+		record, err := createRecordOp(actor, op.Request, extra)
+		if err != nil {
+			return err
+		}
+		*op.Record = *record
+		return nil
+	case models.ChangeOpUpdate:
+		_, err := updateRecordOp(actor, op.Record, op.Request, extra)
+		return err
+	case models.ChangeOpDelete:
+		if op.AllowServer {
+			return removeRecordOp(actor, op.Record, extra)
+		}
+		return deleteRecordOp(actor, op.Record, extra)
+	case batchOpHide:
+		return hideRecordOp(actor, op.Record, extra)
+	case models.ChangeOpEnable, models.ChangeOpDisable:
+		_, err := setRecordStatusOp(actor, op.Record, op.Op == models.ChangeOpEnable, extra)
+		return err
+	default:
+		return newRecordOpError(http.StatusBadRequest, "Unsupported batch operation: "+op.Op)
+	}
+}
+
+// undoBatchOp reverts an applied op. Deleted records are created again at
+// the provider, so they come back with a new ID.
+func undoBatchOp(actor auditActor, op *batchOp, extra gin.H) error {
+	switch op.Op {
+	case models.ChangeOpCreate:
+		return removeRecordOp(actor, op.Record, extra)
+	case models.ChangeOpUpdate:
+		_, err := updateRecordOp(actor, op.Record, recordRequestFrom(&op.before), extra)
+		return err
+	case models.ChangeOpDelete:
+		record, err := createRecordOp(actor, recordRequestFrom(&op.before), extra)
+		if err != nil {
+			return err
+		}
+		if !op.before.Active {
+			if _, err := setRecordStatusOp(actor, record, false, extra); err != nil {
+				return err
+			}
+		}
+		*op.Record = *record
+		return nil
+	case batchOpHide:
+		return unhideRecordOp(actor, op.Record, extra)
+	case models.ChangeOpEnable, models.ChangeOpDisable:
+		_, err := setRecordStatusOp(actor, op.Record, op.before.Active, extra)
+		return err
+	default:
+		return newRecordOpError(http.StatusBadRequest, "Unsupported batch operation: "+op.Op)
+	}
+}
+
+// unhideRecordOp puts a hidden record back under management
+func unhideRecordOp(actor auditActor, record *models.DNSRecord, extra gin.H) error {
+	before := *record
+
+	record.Managed = true
+	if err := database.DB.Save(record).Error; err != nil {
+		*record = before
+		return newRecordOpError(http.StatusInternalServerError, "Failed to restore hidden record")
+	}
+
+	logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeRecord, record.ID, mergeDetails(gin.H{
+		"domain":      record.FullDomain,
+		"record_type": record.RecordType,
+		"action":      "unhide",
+	}, extra), before, *record)
+
+	publishActorEvent(actor, events.RecordUpdated, gin.H{"record": *record})
+
+	return nil
+}
@@ -0,0 +1,541 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReviewChangeRequest represents an approve or reject decision
+type ReviewChangeRequest struct {
+	Comment string `json:"comment"`
+}
+
+// ProtectedZoneRequest represents the request to protect a zone
+type ProtectedZoneRequest struct {
+	Zone string `json:"zone" binding:"required"`
+}
+
+// changeOpPermissions maps change operations to the permission needed to approve them
+var changeOpPermissions = map[string]string{
+	models.ChangeOpCreate:  auth.PermRecordsWrite,
+	models.ChangeOpUpdate:  auth.PermRecordsWrite,
+	models.ChangeOpDelete:  auth.PermRecordsDelete,
+	models.ChangeOpEnable:  auth.PermRecordsToggle,
+	models.ChangeOpDisable: auth.PermRecordsToggle,
+}
+
+// hasPermission reports whether the caller's role, and token scopes if any, grant permission
+func hasPermission(c *gin.Context, permission string) bool {
+	if !auth.RoleHasPermission(c.GetString(auth.ContextRole), permission) {
+		return false
+	}
+	if scopes, ok := c.Get(auth.ContextScopes); ok {
+		return auth.ScopesAllow(scopes.([]string), permission)
+	}
+	return true
+}
+
+// serverRecordsApproval reports whether server record changes need approval:
+// on unless APPROVAL_SERVER_RECORDS is set to a false value
+func serverRecordsApproval() bool {
+	value, ok := os.LookupEnv("APPROVAL_SERVER_RECORDS")
+	return !ok || strings.TrimSpace(value) == "" || auth.IsTruthy(value)
+}
+
+// approvalReason returns why a change touching domains needs four-eyes
+// approval, or "" when it can be applied directly
+func approvalReason(domains []string, isServer bool) (string, error) {
+	if isServer && serverRecordsApproval() {
+		return "server record", nil
+	}
+
+	var zones []models.ProtectedZone
+	if err := database.DB.Find(&zones).Error; err != nil {
+		return "", fmt.Errorf("failed to load protected zones: %w", err)
+	}
+	for _, zone := range zones {
+		for _, domain := range domains {
+			if auth.DomainInZone(domain, zone.Zone) {
+				return "protected zone " + zone.Zone, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// requireApproval files a change request instead of applying the change when
+// the affected domains or record need approval. It returns true when the
+// request was handled (queued or failed) and the caller must stop.
+func requireApproval(c *gin.Context, operation string, record *models.DNSRecord, domain string, payload interface{}) bool {
+	domains := []string{domain}
+	isServer := false
+	if record != nil {
+		domains = append(domains, record.FullDomain)
+		isServer = record.IsServer
+	}
+	if req, ok := payload.(CreateRecordRequest); ok && req.IsServer {
+		isServer = true
+	}
+
+	reason, err := approvalReason(domains, isServer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if reason == "" {
+		return false
+	}
+
+	change := models.ChangeRequest{
+		Operation:   operation,
+		Domain:      domain,
+		Reason:      reason,
+		Status:      models.ChangeStatusPending,
+		RequestedBy: c.GetString(auth.ContextUsername),
+	}
+	if payload != nil {
+		payloadJSON, _ := json.Marshal(payload)
+		change.Payload = string(payloadJSON)
+	}
+	if record != nil {
+		change.RecordID = record.ID
+		snapshotJSON, _ := json.Marshal(record)
+		change.Snapshot = string(snapshotJSON)
+	}
+
+	if err := database.DB.Create(&change).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save change request"})
+		return true
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeChange, change.ID, gin.H{
+		"operation": change.Operation,
+		"domain":    change.Domain,
+		"record_id": change.RecordID,
+		"reason":    change.Reason,
+	}, nil, nil)
+
+	publishEvent(c, events.ChangeRequested, gin.H{"change_request": change})
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":        "Change requires approval (" + reason + "), change request submitted",
+		"change_request": change,
+	})
+	return true
+}
+
+// GetChangeRequests lists change requests, newest first, optionally filtered by status
+func GetChangeRequests(c *gin.Context) {
+	query := database.DB.Order("id DESC").Limit(200)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var changes []models.ChangeRequest
+	if err := query.Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change requests"})
+		return
+	}
+
+	scope := zoneScope(c)
+	visible := make([]models.ChangeRequest, 0, len(changes))
+	for _, change := range changes {
+		if scope.CanView(change.Domain) {
+			visible = append(visible, change)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"change_requests": visible})
+}
+
+// ApproveChangeRequest executes a pending change on behalf of a second user
+func ApproveChangeRequest(c *gin.Context) {
+	change, ok := loadReviewableChange(c)
+	if !ok {
+		return
+	}
+
+	var req ReviewChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Scheduled changes are handed to the scheduler instead of running now
+	status := models.ChangeStatusApproved
+	if change.ExecuteAt != nil {
+		status = models.ChangeStatusScheduled
+	}
+
+	// Claim the request so concurrent approvals cannot execute it twice
+	now := time.Now()
+	reviewer := c.GetString(auth.ContextUsername)
+	claim := database.DB.Model(&models.ChangeRequest{}).
+		Where("id = ? AND status = ?", change.ID, models.ChangeStatusPending).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewed_by":    reviewer,
+			"review_comment": req.Comment,
+			"reviewed_at":    now,
+		})
+	if claim.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve change request"})
+		return
+	}
+	if claim.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Change request is no longer pending"})
+		return
+	}
+	before := *change
+	change.Status = status
+	change.ReviewedBy = reviewer
+	change.ReviewComment = req.Comment
+	change.ReviewedAt = &now
+
+	if change.ExecuteAt != nil {
+		logAuditChange(c, models.ActionUpdate, models.ResourceTypeChange, change.ID, gin.H{
+			"operation":  change.Operation,
+			"domain":     change.Domain,
+			"action":     "approve",
+			"status":     change.Status,
+			"execute_at": change.ExecuteAt,
+		}, before, *change)
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Change request approved, scheduled for " + change.ExecuteAt.Format(time.RFC3339),
+			"change_request": change,
+		})
+		return
+	}
+
+	record, err := applyChangeRequest(requestActor(c), change, gin.H{"approved_by": reviewer})
+	if err != nil {
+		var opErr *recordOpError
+		httpStatus := http.StatusInternalServerError
+		if errors.As(err, &opErr) {
+			httpStatus = opErr.Status
+		}
+		c.JSON(httpStatus, gin.H{
+			"error":          "Change approved but failed to apply: " + err.Error(),
+			"change_request": change,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Change request approved and applied",
+		"change_request": change,
+		"record":         record,
+	})
+}
+
+// RejectChangeRequest declines a pending change
+func RejectChangeRequest(c *gin.Context) {
+	change, ok := loadReviewableChange(c)
+	if !ok {
+		return
+	}
+
+	var req ReviewChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	finishChangeRequest(c, change, models.ChangeStatusRejected, req.Comment)
+}
+
+// CancelChangeRequest lets the requester (or an admin) withdraw a pending or scheduled change
+func CancelChangeRequest(c *gin.Context) {
+	change, ok := loadChangeRequest(c, models.ChangeStatusPending, models.ChangeStatusScheduled)
+	if !ok {
+		return
+	}
+
+	if change.RequestedBy != c.GetString(auth.ContextUsername) && !hasPermission(c, auth.PermAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the requester or an admin can cancel a change request"})
+		return
+	}
+
+	finishChangeRequest(c, change, models.ChangeStatusCancelled, "")
+}
+
+// finishChangeRequest moves a waiting change to a terminal status without executing it
+func finishChangeRequest(c *gin.Context, change *models.ChangeRequest, status, comment string) {
+	now := time.Now()
+	result := database.DB.Model(&models.ChangeRequest{}).
+		Where("id = ? AND status = ?", change.ID, change.Status).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewed_by":    c.GetString(auth.ContextUsername),
+			"review_comment": comment,
+			"reviewed_at":    now,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update change request"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Change request is no longer " + change.Status})
+		return
+	}
+
+	before := *change
+	change.Status = status
+	change.ReviewedBy = c.GetString(auth.ContextUsername)
+	change.ReviewComment = comment
+	change.ReviewedAt = &now
+
+	logAuditChange(c, models.ActionUpdate, models.ResourceTypeChange, change.ID, gin.H{
+		"operation": change.Operation,
+		"domain":    change.Domain,
+		"action":    status,
+	}, before, *change)
+
+	eventType := events.ChangeRejected
+	if status == models.ChangeStatusCancelled {
+		eventType = events.ChangeCancelled
+	}
+	publishEvent(c, eventType, gin.H{"change_request": *change})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Change request " + status,
+		"change_request": change,
+	})
+}
+
+// loadChangeRequest loads the change request named in the URL and checks it
+// is in one of the given statuses
+func loadChangeRequest(c *gin.Context, statuses ...string) (*models.ChangeRequest, bool) {
+	if !requireInteractiveUser(c) {
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change request ID"})
+		return nil, false
+	}
+
+	var change models.ChangeRequest
+	if err := database.DB.First(&change, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change request not found"})
+		return nil, false
+	}
+
+	for _, status := range statuses {
+		if change.Status == status {
+			return &change, true
+		}
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": "Change request is already " + change.Status})
+	return nil, false
+}
+
+// loadReviewableChange loads a pending change and checks the caller may review it:
+// a different user holding the operation's permission for the affected zone
+func loadReviewableChange(c *gin.Context) (*models.ChangeRequest, bool) {
+	change, ok := loadChangeRequest(c, models.ChangeStatusPending)
+	if !ok {
+		return nil, false
+	}
+
+	if change.RequestedBy == c.GetString(auth.ContextUsername) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Change requests must be reviewed by a different user"})
+		return nil, false
+	}
+
+	if permission := changeOpPermissions[change.Operation]; !hasPermission(c, permission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied: reviewing this change requires '" + permission + "'"})
+		return nil, false
+	}
+
+	domains := []string{change.Domain}
+	var snapshot models.DNSRecord
+	if change.Snapshot != "" && json.Unmarshal([]byte(change.Snapshot), &snapshot) == nil {
+		domains = append(domains, snapshot.FullDomain)
+	}
+	if !requireZoneEdit(c, domains...) {
+		return nil, false
+	}
+
+	return change, true
+}
+
+// applyChangeRequest executes a claimed change request, stores its outcome and
+// audits it as actor; extra is added to the audit details of the record change
+func applyChangeRequest(actor auditActor, change *models.ChangeRequest, extra gin.H) (*models.DNSRecord, error) {
+	extra = mergeDetails(gin.H{
+		"change_request_id": change.ID,
+		"requested_by":      change.RequestedBy,
+	}, extra)
+	record, err := executeChangeRequest(actor, change, extra)
+
+	before := *change
+	if err != nil {
+		change.Status = models.ChangeStatusFailed
+		change.Error = err.Error()
+	} else {
+		change.Status = models.ChangeStatusApplied
+		if record != nil {
+			change.ResultRecordID = record.ID
+		}
+	}
+	now := time.Now()
+	change.ExecutedAt = &now
+	if saveErr := database.DB.Save(change).Error; saveErr != nil {
+		log.Printf("applyChangeRequest: Failed to save result of change request %d: %v", change.ID, saveErr)
+	}
+
+	logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeChange, change.ID, gin.H{
+		"operation": change.Operation,
+		"domain":    change.Domain,
+		"action":    "apply",
+		"status":    change.Status,
+	}, before, *change)
+
+	if err == nil {
+		publishActorEvent(actor, events.ChangeApplied, gin.H{"change_request": *change})
+	}
+
+	return record, err
+}
+
+// executeChangeRequest applies an approved change through the normal record operations
+func executeChangeRequest(actor auditActor, change *models.ChangeRequest, extra gin.H) (*models.DNSRecord, error) {
+	var payload CreateRecordRequest
+	if change.Payload != "" {
+		if err := json.Unmarshal([]byte(change.Payload), &payload); err != nil {
+			return nil, fmt.Errorf("invalid change payload: %w", err)
+		}
+	}
+
+	// The zone may have changed while the change waited for approval or its
+	// scheduled time, so the lint rules are checked again
+	if change.Operation == models.ChangeOpCreate {
+		if _, err := lintRequest(nil, payload); err != nil {
+			return nil, err
+		}
+		return createRecordOp(actor, payload, extra)
+	}
+
+	var record models.DNSRecord
+	if err := database.DB.First(&record, change.RecordID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newRecordOpError(http.StatusConflict, "Record no longer exists")
+		}
+		return nil, err
+	}
+
+	// Refuse to apply a change reviewed against a state that no longer exists.
+	// Scheduled changes carry the complete target state, so they may follow
+	// other changes to the same record within a maintenance window.
+	var snapshot models.DNSRecord
+	if change.ExecuteAt == nil && json.Unmarshal([]byte(change.Snapshot), &snapshot) == nil &&
+		!snapshot.UpdatedAt.Equal(record.UpdatedAt) {
+		return nil, newRecordOpError(http.StatusConflict, "Record changed since the change was requested")
+	}
+
+	switch change.Operation {
+	case models.ChangeOpUpdate:
+		if changesDNSFields(&record, payload) {
+			if _, err := lintRequest(&record, payload); err != nil {
+				return &record, err
+			}
+		}
+		_, err := updateRecordOp(actor, &record, payload, extra)
+		return &record, err
+	case models.ChangeOpDelete:
+		return &record, deleteRecordOp(actor, &record, extra)
+	case models.ChangeOpEnable, models.ChangeOpDisable:
+		_, err := setRecordStatusOp(actor, &record, change.Operation == models.ChangeOpEnable, extra)
+		return &record, err
+	default:
+		return nil, fmt.Errorf("unknown change operation %q", change.Operation)
+	}
+}
+
+// GetProtectedZones returns the zones whose changes require approval
+func GetProtectedZones(c *gin.Context) {
+	var zones []models.ProtectedZone
+	if err := database.DB.Order("zone ASC").Find(&zones).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch protected zones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"zones":                   zones,
+		"server_records_approval": serverRecordsApproval(),
+	})
+}
+
+// CreateProtectedZone requires approval for every record change within a zone
+func CreateProtectedZone(c *gin.Context) {
+	var req ProtectedZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	zone := models.ProtectedZone{
+		Zone:      auth.NormalizeDomain(req.Zone),
+		CreatedBy: c.GetString(auth.ContextUsername),
+	}
+	if zone.Zone == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Zone is required"})
+		return
+	}
+
+	if err := database.DB.Create(&zone).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Zone is already protected"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeZone, zone.ID, gin.H{"zone": zone.Zone}, nil, zone)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Zone protected successfully",
+		"zone":    zone,
+	})
+}
+
+// DeleteProtectedZone lifts the approval requirement from a zone
+func DeleteProtectedZone(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid protected zone ID"})
+		return
+	}
+
+	var zone models.ProtectedZone
+	if err := database.DB.First(&zone, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Protected zone not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&zone).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete protected zone"})
+		return
+	}
+
+	logAuditChange(c, models.ActionDelete, models.ResourceTypeZone, zone.ID, gin.H{"zone": zone.Zone}, zone, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Protected zone removed successfully"})
+}
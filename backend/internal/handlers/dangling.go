@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/services"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDanglingRecords reports CNAMEs whose targets are missing, unmanaged or
+// no longer resolve. Pass resolve=false to skip DNS lookups of targets
+// outside the synced zones and severity to keep one severity only.
+func GetDanglingRecords(c *gin.Context) {
+	var resolve services.HostResolver = services.ResolveHost
+	if c.Query("resolve") == "false" {
+		resolve = nil
+	}
+
+	findings, err := findDanglingRecords(resolve)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+		return
+	}
+
+	scope := zoneScope(c)
+	severity := c.Query("severity")
+	visible := make([]services.DanglingFinding, 0, len(findings))
+	bySeverity := map[string]int{
+		services.SeverityHigh:   0,
+		services.SeverityMedium: 0,
+		services.SeverityLow:    0,
+	}
+	for _, finding := range findings {
+		if !scope.CanView(finding.Domain) || (severity != "" && finding.Severity != severity) {
+			continue
+		}
+		visible = append(visible, finding)
+		bySeverity[finding.Severity]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"findings":    visible,
+		"count":       len(visible),
+		"by_severity": bySeverity,
+		"checked_at":  time.Now().UTC(),
+	})
+}
+
+// findDanglingRecords runs the dangling CNAME analysis over every stored
+// record, hidden ones included since they still exist at the provider
+func findDanglingRecords(resolve services.HostResolver) ([]services.DanglingFinding, error) {
+	var records []models.DNSRecord
+	if err := database.DB.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	synced := make([]services.DNSRecordSync, 0, len(records))
+	managed := make(map[string]bool)
+	cnameIDs := make(map[string]uint)
+	for _, record := range records {
+		synced = append(synced, services.DNSRecordSync{
+			ZoneID:           record.ZoneID,
+			ZoneName:         record.ZoneName,
+			FullDomain:       record.FullDomain,
+			RecordType:       record.RecordType,
+			TargetValue:      record.TargetValue,
+			TTL:              record.TTL,
+			Active:           record.Active,
+			ProviderRecordID: record.ProviderRecordID,
+		})
+		domain := strings.ToLower(record.FullDomain)
+		if record.Managed {
+			managed[domain] = true
+		}
+		if record.RecordType == models.RecordTypeCNAME {
+			cnameIDs[domain] = record.ID
+		}
+	}
+
+	findings := services.FindDanglingCNAMEs(synced, managed, resolve)
+	for i := range findings {
+		findings[i].RecordID = cnameIDs[strings.ToLower(findings[i].Domain)]
+	}
+	return findings, nil
+}
+
+// checkDanglingAfterSync runs the dangling CNAME analysis in the background
+// after a sync when DANGLING_CHECK_ON_SYNC is set, publishing the findings
+func checkDanglingAfterSync() {
+	if !auth.IsTruthy(os.Getenv("DANGLING_CHECK_ON_SYNC")) {
+		return
+	}
+
+	go func() {
+		findings, err := findDanglingRecords(services.ResolveHost)
+		if err != nil {
+			log.Printf("Dangling: Failed to check records: %v", err)
+			return
+		}
+		log.Printf("Dangling: Found %d dangling CNAME(s)", len(findings))
+		if len(findings) == 0 {
+			return
+		}
+
+		publishActorEvent(systemActor("dangling"), events.DanglingDetected, gin.H{
+			"count":    len(findings),
+			"findings": findings,
+		})
+	}()
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dyndnsMaxHosts limits how many hostnames one update may carry
+const dyndnsMaxHosts = 20
+
+// dyndns2 return codes
+const (
+	dyndnsGood     = "good"
+	dyndnsNoChange = "nochg"
+	dyndnsBadAuth  = "badauth"
+	dyndnsNotFQDN  = "notfqdn"
+	dyndnsNoHost   = "nohost"
+	dyndnsNumHost  = "numhost"
+	dyndnsDNSErr   = "dnserr"
+)
+
+// DynDNSCredentialRequest represents the request to create a DynDNS credential
+type DynDNSCredentialRequest struct {
+	Username  string   `json:"username" binding:"required"`
+	Hostnames []string `json:"hostnames" binding:"required"` // managed A/AAAA records this credential may update
+}
+
+// GetDynDNSCredentials returns all DynDNS credentials (without secrets)
+func GetDynDNSCredentials(c *gin.Context) {
+	var credentials []models.DynDNSCredential
+	if err := database.DB.Order("username ASC").Find(&credentials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch DynDNS credentials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// CreateDynDNSCredential issues basic auth credentials for /nic/update. The
+// password is only returned in this response.
+func CreateDynDNSCredential(c *gin.Context) {
+	var req DynDNSCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if !credentialUsernamePattern.MatchString(username) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username may only contain letters, digits, '.', '_' and '-'"})
+		return
+	}
+
+	hostnames := make([]string, 0, len(req.Hostnames))
+	for _, hostname := range req.Hostnames {
+		hostname = auth.NormalizeDomain(hostname)
+		if hostname == "" {
+			continue
+		}
+
+		var count int64
+		database.DB.Model(&models.DNSRecord{}).
+			Where("full_domain = ? AND record_type IN ? AND managed = ?", hostname, []string{models.RecordTypeA, models.RecordTypeAAAA}, true).
+			Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No managed A or AAAA record for " + hostname})
+			return
+		}
+		hostnames = append(hostnames, hostname)
+	}
+	if len(hostnames) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one hostname is required"})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.DynDNSCredential{}).Where("username = ?", username).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "DynDNS credential " + username + " already exists"})
+		return
+	}
+
+	secret, hash, err := auth.GenerateCredentialSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate credential"})
+		return
+	}
+
+	credential := models.DynDNSCredential{
+		Username:   username,
+		SecretHash: hash,
+		Hostnames:  strings.Join(hostnames, ","),
+		CreatedBy:  c.GetString(auth.ContextUsername),
+	}
+
+	if err := database.DB.Create(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save DynDNS credential"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeDynDNS, credential.ID, gin.H{
+		"username":  credential.Username,
+		"hostnames": hostnames,
+	}, nil, credential)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "DynDNS credential created successfully, store the password now as it will not be shown again",
+		"password":   secret,
+		"credential": credential,
+	})
+}
+
+// DeleteDynDNSCredential revokes a DynDNS credential
+func DeleteDynDNSCredential(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid DynDNS credential ID"})
+		return
+	}
+
+	var credential models.DynDNSCredential
+	if err := database.DB.First(&credential, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DynDNS credential not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete DynDNS credential"})
+		return
+	}
+
+	logAuditChange(c, models.ActionDelete, models.ResourceTypeDynDNS, credential.ID, gin.H{
+		"username":  credential.Username,
+		"hostnames": credential.Hostnames,
+	}, credential, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "DynDNS credential deleted successfully"})
+}
+
+// DynDNSUpdate implements the dyndns2 update protocol:
+// GET /nic/update?hostname=a.example.com,b.example.com&myip=1.2.3.4
+// It answers one return code per hostname, in order, as plain text.
+func DynDNSUpdate(c *gin.Context) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		c.Header("WWW-Authenticate", `Basic realm="dnsmesh-dyndns"`)
+		c.String(http.StatusUnauthorized, dyndnsBadAuth)
+		return
+	}
+
+	credential, err := auth.AuthenticateDynDNSCredential(database.DB, username, password)
+	if err != nil {
+		if !errors.Is(err, auth.ErrDynDNSCredentialInvalid) {
+			log.Printf("DynDNSUpdate: Failed to authenticate '%s': %v", username, err)
+			c.String(http.StatusOK, "911")
+			return
+		}
+		log.Printf("DynDNSUpdate: Rejected credential '%s' from %s", username, c.ClientIP())
+		c.Header("WWW-Authenticate", `Basic realm="dnsmesh-dyndns"`)
+		c.String(http.StatusUnauthorized, dyndnsBadAuth)
+		return
+	}
+
+	hostnames := auth.SplitList(c.Query("hostname"))
+	if len(hostnames) == 0 {
+		c.String(http.StatusOK, dyndnsNotFQDN)
+		return
+	}
+	if len(hostnames) > dyndnsMaxHosts {
+		c.String(http.StatusOK, dyndnsNumHost)
+		return
+	}
+
+	addresses := dyndnsAddresses(c)
+	if len(addresses) == 0 {
+		c.String(http.StatusOK, dyndnsDNSErr)
+		return
+	}
+
+	actor := auditActor{
+		Username:   "dyndns:" + credential.Username,
+		AuthMethod: auth.MethodDynDNS,
+		IPAddress:  c.ClientIP(),
+	}
+
+	results := make([]string, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		results = append(results, updateDynDNSHost(actor, credential, hostname, addresses))
+	}
+
+	ips := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		ips = append(ips, address.String())
+	}
+	database.DB.Model(credential).UpdateColumn("last_ip", strings.Join(ips, ","))
+
+	c.String(http.StatusOK, strings.Join(results, "\n"))
+}
+
+// dyndnsAddresses returns the addresses to publish: myip (which some clients
+// send as "v4,v6") and myipv6, falling back to the caller's address
+func dyndnsAddresses(c *gin.Context) []net.IP {
+	values := auth.SplitList(c.Query("myip"))
+	values = append(values, auth.SplitList(c.Query("myipv6"))...)
+	if len(values) == 0 {
+		values = []string{c.ClientIP()}
+	}
+
+	var addresses []net.IP
+	for _, value := range values {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			log.Printf("DynDNSUpdate: Ignoring invalid address '%s'", value)
+			continue
+		}
+		addresses = append(addresses, ip)
+	}
+	return addresses
+}
+
+// updateDynDNSHost points the managed A and/or AAAA record of hostname at
+// addresses and returns the dyndns2 code for that hostname
+func updateDynDNSHost(actor auditActor, credential *models.DynDNSCredential, hostname string, addresses []net.IP) string {
+	hostname = auth.NormalizeDomain(hostname)
+	if !strings.Contains(hostname, ".") {
+		return dyndnsNotFQDN
+	}
+	if !auth.DynDNSCredentialAllows(credential, hostname) {
+		return dyndnsNoHost
+	}
+
+	found, changed := false, false
+	ips := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		recordType := models.RecordTypeAAAA
+		if address.To4() != nil {
+			recordType = models.RecordTypeA
+		}
+
+		var record models.DNSRecord
+		if err := database.DB.Where("full_domain = ? AND record_type = ? AND managed = ?", hostname, recordType, true).
+			First(&record).Error; err != nil {
+			continue
+		}
+		found = true
+		ips = append(ips, address.String())
+
+		if net.ParseIP(record.TargetValue).Equal(address) {
+			continue
+		}
+
+		// Devices cannot wait for a second pair of eyes
+		reason, err := approvalReason([]string{record.FullDomain}, record.IsServer)
+		if err != nil {
+			log.Printf("DynDNSUpdate: Failed to check approval for %s: %v", hostname, err)
+			return dyndnsDNSErr
+		}
+		if reason != "" {
+			log.Printf("DynDNSUpdate: Refusing to update %s, it requires approval (%s)", hostname, reason)
+			return dyndnsDNSErr
+		}
+
+		if _, err := updateRecordOp(actor, &record, CreateRecordRequest{
+			FullDomain:   record.FullDomain,
+			RecordType:   record.RecordType,
+			TargetValue:  address.String(),
+			TTL:          record.TTL,
+			IsServer:     record.IsServer,
+			ServerName:   record.ServerName,
+			ServerRegion: record.ServerRegion,
+			Notes:        record.Notes,
+		}, gin.H{"dyndns_credential": credential.Username}); err != nil {
+			log.Printf("DynDNSUpdate: Failed to update %s %s: %v", recordType, hostname, err)
+			return dyndnsDNSErr
+		}
+		changed = true
+	}
+
+	if !found {
+		return dyndnsNoHost
+	}
+	if changed {
+		return dyndnsGood + " " + strings.Join(ips, ",")
+	}
+	return dyndnsNoChange + " " + strings.Join(ips, ",")
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"dnsmesh/internal/events"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeatInterval keeps idle connections open through proxies
+const streamHeartbeatInterval = 25 * time.Second
+
+// publishEvent emits a change event attributed to the authenticated user
+func publishEvent(c *gin.Context, eventType string, data gin.H) {
+	publishActorEvent(requestActor(c), eventType, data)
+}
+
+// publishActorEvent emits a change event attributed to an explicit actor
+func publishActorEvent(actor auditActor, eventType string, data gin.H) {
+	if actor.BatchID != "" {
+		data["batch_id"] = actor.BatchID
+	}
+	events.Publish(eventType, actor.Username, data)
+}
+
+// StreamEvents pushes record and provider change events as Server-Sent Events.
+// Clients resume with the Last-Event-ID header (or last_event_id query parameter);
+// a "resync" event tells them missed events are unavailable and they should reload.
+func StreamEvents(c *gin.Context) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	scope := zoneScope(c)
+	lastID, sameEpoch := parseStreamEventID(lastEventID)
+	replay, complete, ch, cancel := events.SubscribeSince(lastID, 256)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	if lastEventID != "" && (!sameEpoch || !complete) {
+		fmt.Fprint(c.Writer, "event: resync\ndata: {}\n\n")
+	}
+	for _, event := range replay {
+		if eventVisible(scope, event) {
+			writeStreamEvent(c, event)
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and replays
+				return
+			}
+			if !eventVisible(scope, event) {
+				continue
+			}
+			writeStreamEvent(c, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeStreamEvent writes one event in text/event-stream format
+func writeStreamEvent(c *gin.Context, event events.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.Writer, "id: %d-%d\nevent: %s\ndata: %s\n\n", events.DefaultBus.Epoch(), event.ID, event.Type, data)
+}
+
+// parseStreamEventID parses "<epoch>-<id>" and reports whether it was issued by this process
+func parseStreamEventID(value string) (uint64, bool) {
+	epochStr, idStr, found := strings.Cut(value, "-")
+	if !found {
+		return 0, false
+	}
+
+	epoch, err := strconv.ParseInt(epochStr, 10, 64)
+	if err != nil || epoch != events.DefaultBus.Epoch() {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
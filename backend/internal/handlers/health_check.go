@@ -0,0 +1,672 @@
+package handlers
+
+import (
+	"context"
+	"crypto/tls"
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthCheckRequest represents the request to create or update a health check
+type HealthCheckRequest struct {
+	ServerRecordID    uint   `json:"server_record_id" binding:"required"`
+	Type              string `json:"type"` // tcp (default), http or https
+	Port              int    `json:"port"`
+	Path              string `json:"path"`
+	ExpectedStatus    int    `json:"expected_status"`
+	IntervalSeconds   int    `json:"interval_seconds"`
+	TimeoutSeconds    int    `json:"timeout_seconds"`
+	FailureThreshold  int    `json:"failure_threshold"`
+	RecoveryThreshold int    `json:"recovery_threshold"`
+	StandbyIP         string `json:"standby_ip"`
+	StandbyRecordID   uint   `json:"standby_record_id"`
+	Enabled           *bool  `json:"enabled"`
+}
+
+// GetHealthChecks returns the health checks of the servers the caller can see
+func GetHealthChecks(c *gin.Context) {
+	var checks []models.HealthCheck
+	if err := database.DB.Order("id ASC").Find(&checks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch health checks"})
+		return
+	}
+
+	scope := zoneScope(c)
+	visible := make([]models.HealthCheck, 0, len(checks))
+	for _, check := range checks {
+		var server models.DNSRecord
+		if err := database.DB.First(&server, check.ServerRecordID).Error; err != nil || !scope.CanView(server.FullDomain) {
+			continue
+		}
+		visible = append(visible, check)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"health_checks": visible})
+}
+
+// CreateHealthCheck attaches a health check with a standby address to a server record
+func CreateHealthCheck(c *gin.Context) {
+	var req HealthCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var check models.HealthCheck
+	if !applyHealthCheckRequest(c, &check, req) {
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.HealthCheck{}).Where("server_record_id = ?", check.ServerRecordID).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Server already has a health check"})
+		return
+	}
+
+	check.Status = models.HealthStatusUnknown
+	check.CreatedBy = c.GetString(auth.ContextUsername)
+	if err := database.DB.Create(&check).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save health check"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeHealth, check.ID, gin.H{
+		"server_record_id": check.ServerRecordID,
+		"type":             check.Type,
+		"port":             check.Port,
+	}, nil, check)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Health check created successfully", "health_check": check})
+}
+
+// UpdateHealthCheck changes a health check's probe or standby settings. Checks
+// that are failed over must recover (or be deleted) first.
+func UpdateHealthCheck(c *gin.Context) {
+	check, ok := loadHealthCheck(c)
+	if !ok {
+		return
+	}
+
+	var req HealthCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	healthCheckLocks.Lock(check.ID)
+	defer healthCheckLocks.Unlock(check.ID)
+
+	// Reload under the lock, the checker may have just failed over
+	if err := database.DB.First(check, check.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Health check not found"})
+		return
+	}
+	if check.FailedOver {
+		c.JSON(http.StatusConflict, gin.H{"error": "Health check is failed over, wait for recovery or delete it to switch back"})
+		return
+	}
+
+	before := *check
+	if !applyHealthCheckRequest(c, check, req) {
+		return
+	}
+	if check.ServerRecordID != before.ServerRecordID {
+		var existing int64
+		database.DB.Model(&models.HealthCheck{}).Where("server_record_id = ? AND id <> ?", check.ServerRecordID, check.ID).Count(&existing)
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Server already has a health check"})
+			return
+		}
+	}
+
+	// Start counting again with the new settings
+	check.Status = models.HealthStatusUnknown
+	check.ConsecutiveFailures = 0
+	check.ConsecutiveSuccesses = 0
+	check.LastError = ""
+
+	if err := database.DB.Save(check).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save health check"})
+		return
+	}
+
+	logAuditChange(c, models.ActionUpdate, models.ResourceTypeHealth, check.ID, gin.H{
+		"server_record_id": check.ServerRecordID,
+		"type":             check.Type,
+		"port":             check.Port,
+	}, before, *check)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Health check updated successfully", "health_check": check})
+}
+
+// DeleteHealthCheck removes a health check, switching its records back to the
+// primary address first if it is failed over
+func DeleteHealthCheck(c *gin.Context) {
+	check, ok := loadHealthCheck(c)
+	if !ok {
+		return
+	}
+
+	healthCheckLocks.Lock(check.ID)
+	defer healthCheckLocks.Unlock(check.ID)
+
+	// Reload under the lock, the checker may have just failed over or recovered
+	if err := database.DB.First(check, check.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Health check not found"})
+		return
+	}
+
+	if check.FailedOver {
+		if err := recoverHealthCheck(requestActor(c), check, "health check deleted"); err != nil {
+			saveHealthCheckState(check)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch records back to the primary address: " + err.Error()})
+			return
+		}
+	}
+
+	if err := database.DB.Delete(check).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete health check"})
+		return
+	}
+
+	logAuditChange(c, models.ActionDelete, models.ResourceTypeHealth, check.ID, gin.H{
+		"server_record_id": check.ServerRecordID,
+		"type":             check.Type,
+	}, *check, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Health check deleted successfully"})
+}
+
+// loadHealthCheck loads the health check named by the :id parameter if the
+// caller may edit its server
+func loadHealthCheck(c *gin.Context) (*models.HealthCheck, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid health check ID"})
+		return nil, false
+	}
+
+	var check models.HealthCheck
+	if err := database.DB.First(&check, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Health check not found"})
+		return nil, false
+	}
+
+	var server models.DNSRecord
+	if err := database.DB.First(&server, check.ServerRecordID).Error; err == nil {
+		if !zoneScope(c).CanView(server.FullDomain) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Health check not found"})
+			return nil, false
+		}
+		if !requireZoneEdit(c, server.FullDomain) {
+			return nil, false
+		}
+	}
+
+	return &check, true
+}
+
+// applyHealthCheckRequest validates req and copies it onto check
+func applyHealthCheckRequest(c *gin.Context, check *models.HealthCheck, req HealthCheckRequest) bool {
+	var server models.DNSRecord
+	if err := database.DB.Where("id = ? AND managed = ?", req.ServerRecordID, true).First(&server).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server record not found"})
+		return false
+	}
+	if !server.IsServer || server.RecordType != models.RecordTypeA {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Health checks can only be attached to server A records"})
+		return false
+	}
+	if !requireZoneEdit(c, server.FullDomain) {
+		return false
+	}
+
+	checkType := strings.ToLower(strings.TrimSpace(req.Type))
+	if checkType == "" {
+		checkType = models.HealthCheckTCP
+	}
+	defaultPort := 0
+	switch checkType {
+	case models.HealthCheckHTTP:
+		defaultPort = 80
+	case models.HealthCheckHTTPS:
+		defaultPort = 443
+	case models.HealthCheckTCP:
+		if req.Port == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "port is required for tcp checks"})
+			return false
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be tcp, http or https"})
+		return false
+	}
+	if req.Port == 0 {
+		req.Port = defaultPort
+	}
+	if req.Port < 1 || req.Port > 65535 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "port must be between 1 and 65535"})
+		return false
+	}
+
+	if (req.StandbyIP == "") == (req.StandbyRecordID == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Specify exactly one of standby_ip or standby_record_id"})
+		return false
+	}
+	if req.StandbyIP != "" {
+		ip := net.ParseIP(strings.TrimSpace(req.StandbyIP))
+		if ip == nil || ip.To4() == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "standby_ip must be an IPv4 address"})
+			return false
+		}
+		req.StandbyIP = ip.String()
+		if req.StandbyIP == server.TargetValue {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "standby_ip must differ from the server's address"})
+			return false
+		}
+	}
+	if req.StandbyRecordID != 0 {
+		var standby models.DNSRecord
+		if err := database.DB.Where("id = ? AND managed = ?", req.StandbyRecordID, true).First(&standby).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Standby server record not found"})
+			return false
+		}
+		if !standby.IsServer || standby.RecordType != models.RecordTypeA {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Standby must be a server A record"})
+			return false
+		}
+		if standby.ID == server.ID || standby.TargetValue == server.TargetValue {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Standby server must have a different address"})
+			return false
+		}
+	}
+
+	check.ServerRecordID = server.ID
+	check.Type = checkType
+	check.Port = req.Port
+	check.Path = ""
+	check.ExpectedStatus = 0
+	if checkType != models.HealthCheckTCP {
+		check.Path = req.Path
+		if !strings.HasPrefix(check.Path, "/") {
+			check.Path = "/" + check.Path
+		}
+		check.ExpectedStatus = req.ExpectedStatus
+	}
+	check.IntervalSeconds = positiveOr(req.IntervalSeconds, 30)
+	check.TimeoutSeconds = positiveOr(req.TimeoutSeconds, 5)
+	check.FailureThreshold = positiveOr(req.FailureThreshold, 3)
+	check.RecoveryThreshold = positiveOr(req.RecoveryThreshold, 3)
+	check.StandbyIP = req.StandbyIP
+	check.StandbyRecordID = req.StandbyRecordID
+	check.Enabled = req.Enabled == nil || *req.Enabled
+	return true
+}
+
+// positiveOr returns value, or fallback when value is not positive
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// healthCheckLocks serializes probing, failover and deletion of each check
+var healthCheckLocks = &keyedMutex{locks: make(map[uint]*sync.Mutex)}
+
+// keyedMutex is a set of mutexes addressed by ID
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[uint]*sync.Mutex
+}
+
+func (k *keyedMutex) get(id uint) *sync.Mutex {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	lock, ok := k.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		k.locks[id] = lock
+	}
+	return lock
+}
+
+// Lock locks the mutex of id
+func (k *keyedMutex) Lock(id uint) { k.get(id).Lock() }
+
+// TryLock locks the mutex of id unless it is already held
+func (k *keyedMutex) TryLock(id uint) bool { return k.get(id).TryLock() }
+
+// Unlock unlocks the mutex of id
+func (k *keyedMutex) Unlock(id uint) { k.get(id).Unlock() }
+
+// StartHealthChecker probes enabled health checks in the background, looking
+// for due checks every HEALTH_CHECK_TICK (default 5s). Each check runs on its
+// own interval; slow probes do not hold up the others.
+func StartHealthChecker() {
+	tick := 5 * time.Second
+	if value, err := time.ParseDuration(os.Getenv("HEALTH_CHECK_TICK")); err == nil && value > 0 {
+		tick = value
+	}
+
+	log.Printf("HealthCheck: Looking for due checks every %s", tick)
+
+	go func() {
+		for {
+			runDueHealthChecks()
+			time.Sleep(tick)
+		}
+	}()
+}
+
+// runDueHealthChecks starts a probe for every enabled check whose interval has passed
+func runDueHealthChecks() {
+	var checks []models.HealthCheck
+	if err := database.DB.Where("enabled = ?", true).Find(&checks).Error; err != nil {
+		log.Printf("HealthCheck: Failed to load health checks: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range checks {
+		check := checks[i]
+		if check.LastCheckedAt != nil && now.Sub(*check.LastCheckedAt) < time.Duration(check.IntervalSeconds)*time.Second {
+			continue
+		}
+		if !healthCheckLocks.TryLock(check.ID) {
+			continue // still probing
+		}
+		go func() {
+			defer healthCheckLocks.Unlock(check.ID)
+			runHealthCheck(check.ID)
+		}()
+	}
+}
+
+// runHealthCheck probes one check, updates its counters and fails over or
+// recovers when a threshold is reached. The caller holds the check's lock.
+func runHealthCheck(id uint) {
+	var check models.HealthCheck
+	if err := database.DB.First(&check, id).Error; err != nil || !check.Enabled {
+		return
+	}
+
+	var server models.DNSRecord
+	if err := database.DB.First(&server, check.ServerRecordID).Error; err != nil {
+		log.Printf("HealthCheck: Server record %d of check %d not found", check.ServerRecordID, check.ID)
+		return
+	}
+
+	// While failed over the server record points at the standby, keep probing the primary
+	address := server.TargetValue
+	if check.FailedOver {
+		address = check.PrimaryIP
+	}
+
+	probeErr := probeServer(&check, server.FullDomain, address)
+
+	now := time.Now()
+	check.LastCheckedAt = &now
+	if probeErr != nil {
+		check.ConsecutiveFailures++
+		check.ConsecutiveSuccesses = 0
+		check.LastError = probeErr.Error()
+		if check.ConsecutiveFailures >= check.FailureThreshold {
+			check.Status = models.HealthStatusUnhealthy
+		}
+	} else {
+		check.ConsecutiveSuccesses++
+		check.ConsecutiveFailures = 0
+		check.LastError = ""
+		if !check.FailedOver || check.ConsecutiveSuccesses >= check.RecoveryThreshold {
+			check.Status = models.HealthStatusHealthy
+		}
+	}
+
+	actor := systemActor("healthcheck")
+	switch {
+	case check.Status == models.HealthStatusUnhealthy && !check.FailedOver:
+		log.Printf("HealthCheck: %s (%s) failed %d times: %v", server.FullDomain, address, check.ConsecutiveFailures, probeErr)
+		if err := failoverHealthCheck(actor, &check, &server); err != nil {
+			log.Printf("HealthCheck: Failover of %s failed: %v", server.FullDomain, err)
+		}
+	case check.Status == models.HealthStatusHealthy && check.FailedOver:
+		log.Printf("HealthCheck: %s (%s) recovered after %d successful checks", server.FullDomain, address, check.ConsecutiveSuccesses)
+		if err := recoverHealthCheck(actor, &check, "primary recovered"); err != nil {
+			log.Printf("HealthCheck: Switching %s back failed: %v", server.FullDomain, err)
+		}
+	}
+
+	saveHealthCheckState(&check)
+}
+
+// saveHealthCheckState stores the probe and failover state without touching
+// the settings, which may be edited concurrently
+func saveHealthCheckState(check *models.HealthCheck) {
+	if err := database.DB.Model(&models.HealthCheck{}).Where("id = ?", check.ID).UpdateColumns(map[string]interface{}{
+		"status":                check.Status,
+		"consecutive_failures":  check.ConsecutiveFailures,
+		"consecutive_successes": check.ConsecutiveSuccesses,
+		"last_checked_at":       check.LastCheckedAt,
+		"last_error":            check.LastError,
+		"failed_over":           check.FailedOver,
+		"primary_ip":            check.PrimaryIP,
+		"active_standby_ip":     check.ActiveStandbyIP,
+		"failover_record_ids":   check.FailoverRecordIDs,
+		"failed_over_at":        check.FailedOverAt,
+	}).Error; err != nil {
+		log.Printf("HealthCheck: Failed to save state of check %d: %v", check.ID, err)
+	}
+}
+
+// probeServer connects to address (or requests it over HTTP with the server's
+// hostname) and returns why the server is unhealthy, or nil
+func probeServer(check *models.HealthCheck, hostname, address string) error {
+	timeout := time.Duration(check.TimeoutSeconds) * time.Second
+	hostPort := net.JoinHostPort(address, strconv.Itoa(check.Port))
+
+	if check.Type == models.HealthCheckTCP {
+		conn, err := net.DialTimeout("tcp", hostPort, timeout)
+		if err != nil {
+			return err
+		}
+		conn.Close()
+		return nil
+	}
+
+	// Dial the probed address directly but present the server's hostname, so
+	// virtual hosts and TLS certificates match
+	dialer := &net.Dialer{Timeout: timeout}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, hostPort)
+			},
+			TLSClientConfig:   &tls.Config{ServerName: hostname},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	url := fmt.Sprintf("%s://%s%s", check.Type, net.JoinHostPort(hostname, strconv.Itoa(check.Port)), check.Path)
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if check.ExpectedStatus != 0 {
+		if resp.StatusCode != check.ExpectedStatus {
+			return fmt.Errorf("unexpected status %d, want %d", resp.StatusCode, check.ExpectedStatus)
+		}
+	} else if resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// failoverRecords returns the managed A records linked to the same server as
+// server record that point at its address: the server record itself, other
+// server records sharing the address and plain A records. CNAMEs to those
+// names follow automatically.
+func failoverRecords(server *models.DNSRecord) ([]models.DNSRecord, error) {
+	if server.ServerID == nil {
+		return []models.DNSRecord{*server}, nil
+	}
+
+	var records []models.DNSRecord
+	if err := database.DB.Where("managed = ? AND server_id = ? AND record_type = ? AND target_value = ?",
+		true, *server.ServerID, models.RecordTypeA, server.TargetValue).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// standbyAddress resolves the address check fails over to
+func standbyAddress(check *models.HealthCheck) (string, error) {
+	if check.StandbyIP != "" {
+		return check.StandbyIP, nil
+	}
+
+	var standby models.DNSRecord
+	if err := database.DB.First(&standby, check.StandbyRecordID).Error; err != nil {
+		return "", fmt.Errorf("standby server record %d not found", check.StandbyRecordID)
+	}
+	return standby.TargetValue, nil
+}
+
+// failoverHealthCheck points the records associated with server at the
+// standby address and remembers which ones were switched. Failover bypasses
+// change approval: an outage cannot wait for a reviewer.
+func failoverHealthCheck(actor auditActor, check *models.HealthCheck, server *models.DNSRecord) error {
+	standby, err := standbyAddress(check)
+	if err != nil {
+		return err
+	}
+	if standby == server.TargetValue {
+		return fmt.Errorf("standby address %s is the primary address", standby)
+	}
+
+	records, err := failoverRecords(server)
+	if err != nil {
+		return err
+	}
+
+	primary := server.TargetValue
+	extra := gin.H{"health_check_id": check.ID, "action": "failover", "primary_ip": primary, "standby_ip": standby}
+
+	var swapped []string
+	var failed []string
+	for i := range records {
+		rec := &records[i]
+		if _, err := updateRecordOp(actor, rec, recordRequestWithTarget(rec, standby), extra); err != nil {
+			log.Printf("HealthCheck: Failed to fail over %s: %v", rec.FullDomain, err)
+			failed = append(failed, rec.FullDomain)
+			continue
+		}
+		swapped = append(swapped, strconv.FormatUint(uint64(rec.ID), 10))
+	}
+	if len(swapped) == 0 {
+		return fmt.Errorf("no record could be switched to %s", standby)
+	}
+
+	now := time.Now()
+	check.FailedOver = true
+	check.PrimaryIP = primary
+	check.ActiveStandbyIP = standby
+	check.FailoverRecordIDs = strings.Join(swapped, ",")
+	check.FailedOverAt = &now
+	check.ConsecutiveSuccesses = 0
+
+	details := mergeDetails(gin.H{
+		"server":     server.FullDomain,
+		"records":    swapped,
+		"last_error": check.LastError,
+	}, extra)
+	if len(failed) > 0 {
+		details["failed_records"] = failed
+	}
+	logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeHealth, check.ID, details, nil, nil)
+
+	publishActorEvent(actor, events.ServerFailover, gin.H{
+		"health_check_id": check.ID,
+		"domain":          server.FullDomain,
+		"primary_ip":      primary,
+		"standby_ip":      standby,
+		"record_ids":      swapped,
+		"error":           check.LastError,
+	})
+
+	log.Printf("HealthCheck: Failed over %d record(s) of %s from %s to %s", len(swapped), server.FullDomain, primary, standby)
+	return nil
+}
+
+// recoverHealthCheck switches the records swapped by failover back to the
+// primary address. Records that were changed by hand since are left alone.
+func recoverHealthCheck(actor auditActor, check *models.HealthCheck, reason string) error {
+	extra := gin.H{"health_check_id": check.ID, "action": "recover", "primary_ip": check.PrimaryIP, "reason": reason}
+
+	var restored, remaining []string
+	for _, id := range auth.SplitList(check.FailoverRecordIDs) {
+		var rec models.DNSRecord
+		if err := database.DB.First(&rec, id).Error; err != nil || rec.TargetValue != check.ActiveStandbyIP {
+			continue
+		}
+		if _, err := updateRecordOp(actor, &rec, recordRequestWithTarget(&rec, check.PrimaryIP), extra); err != nil {
+			log.Printf("HealthCheck: Failed to switch %s back: %v", rec.FullDomain, err)
+			remaining = append(remaining, id)
+			continue
+		}
+		restored = append(restored, id)
+	}
+	if len(remaining) > 0 {
+		// Keep the failover state so the next successful probe retries the rest
+		check.FailoverRecordIDs = strings.Join(remaining, ",")
+		return fmt.Errorf("%d record(s) could not be switched back", len(remaining))
+	}
+
+	var domain string
+	var server models.DNSRecord
+	if err := database.DB.First(&server, check.ServerRecordID).Error; err == nil {
+		domain = server.FullDomain
+	}
+
+	logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeHealth, check.ID, mergeDetails(gin.H{
+		"server":     domain,
+		"records":    restored,
+		"standby_ip": check.ActiveStandbyIP,
+	}, extra), nil, nil)
+
+	publishActorEvent(actor, events.ServerRecovered, gin.H{
+		"health_check_id": check.ID,
+		"domain":          domain,
+		"primary_ip":      check.PrimaryIP,
+		"standby_ip":      check.ActiveStandbyIP,
+		"record_ids":      restored,
+	})
+
+	log.Printf("HealthCheck: Switched %d record(s) of %s back to %s", len(restored), domain, check.PrimaryIP)
+
+	check.FailedOver = false
+	check.PrimaryIP = ""
+	check.ActiveStandbyIP = ""
+	check.FailoverRecordIDs = ""
+	check.FailedOverAt = nil
+	return nil
+}
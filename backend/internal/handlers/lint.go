@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/lint"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/services"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lintConfig holds the enabled lint rules and their severities
+var lintConfig = lint.DefaultConfig()
+
+// ConfigureLint sets the lint rules checked on record changes and reports
+func ConfigureLint(cfg lint.Config) {
+	lintConfig = cfg
+}
+
+// lintZone is the records and TTL limits of one zone
+type lintZone struct {
+	records []models.DNSRecord
+	limits  lint.TTLLimits
+}
+
+// loadLintZone loads every stored record of a zone, hidden ones included
+// since they still exist at the provider, and the provider's TTL limits
+func loadLintZone(providerID uint, zoneName string) (lintZone, error) {
+	var zone lintZone
+	if err := database.DB.Where("provider_id = ? AND zone_name = ?", providerID, zoneName).Find(&zone.records).Error; err != nil {
+		return zone, err
+	}
+
+	var provider models.Provider
+	if err := database.DB.First(&provider, providerID).Error; err == nil {
+		capabilities := services.GetProviderCapabilities(provider)
+		zone.limits = lint.TTLLimits{Min: capabilities.MinTTL, Max: capabilities.MaxTTL, Auto: capabilities.AutoTTL}
+	}
+	return zone, nil
+}
+
+// lintRecord checks record, as it would be after a change, against the
+// other records of its zone
+func lintRecord(record models.DNSRecord) ([]lint.Finding, error) {
+	zone, err := loadLintZone(record.ProviderID, record.ZoneName)
+	if err != nil {
+		return nil, newRecordOpError(http.StatusInternalServerError, "Failed to load zone records")
+	}
+	return lintConfig.Check(record, zone.records, zone.limits), nil
+}
+
+// lintCandidate is the record a create or update request would produce.
+// record is nil for creates; the zone is detected from the requested domain.
+func lintCandidate(record *models.DNSRecord, req CreateRecordRequest) (models.DNSRecord, error) {
+	var candidate models.DNSRecord
+	if record != nil {
+		candidate = *record
+	}
+	if record == nil || !strings.EqualFold(record.FullDomain, req.FullDomain) {
+		zoneRecord, err := findZoneForDomain(req.FullDomain)
+		if err != nil {
+			return candidate, newRecordOpError(http.StatusBadRequest, "Cannot determine zone for domain: "+req.FullDomain)
+		}
+		candidate.ProviderID = zoneRecord.ProviderID
+		candidate.ZoneName = zoneRecord.ZoneName
+	}
+	candidate.FullDomain = req.FullDomain
+	candidate.RecordType = req.RecordType
+	candidate.TargetValue = req.TargetValue
+	candidate.TTL = req.TTL
+	return candidate, nil
+}
+
+// lintRequest lints the record a create or update request would produce and
+// fails with the lint errors if there are any
+func lintRequest(record *models.DNSRecord, req CreateRecordRequest) ([]lint.Finding, error) {
+	candidate, err := lintCandidate(record, req)
+	if err != nil {
+		return nil, err
+	}
+	findings, err := lintRecord(candidate)
+	if err != nil {
+		return nil, err
+	}
+	if lint.HasErrors(findings) {
+		return findings, newRecordOpError(http.StatusUnprocessableEntity, lintErrorMessage(findings))
+	}
+	return findings, nil
+}
+
+// lintErrorMessage joins the error findings into one message
+func lintErrorMessage(findings []lint.Finding) string {
+	var messages []string
+	for _, finding := range findings {
+		if finding.Severity == lint.SeverityError {
+			messages = append(messages, fmt.Sprintf("%s (%s)", finding.Message, finding.Rule))
+		}
+	}
+	return "Record failed lint checks: " + strings.Join(messages, "; ")
+}
+
+// respondLintError writes a failed lintRequest, with its findings if the
+// record was rejected by the rules
+func respondLintError(c *gin.Context, findings []lint.Finding, err error) {
+	if findings == nil {
+		respondRecordOpError(c, err)
+		return
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error": err.Error(),
+		"lint":  findings,
+	})
+}
+
+// lintWarnings returns the warnings among findings, never nil
+func lintWarnings(findings []lint.Finding) []lint.Finding {
+	warnings := []lint.Finding{}
+	for _, finding := range findings {
+		if finding.Severity == lint.SeverityWarning {
+			warnings = append(warnings, finding)
+		}
+	}
+	return warnings
+}
+
+// GetRecordsLint lints every managed record against the records of its zone.
+// Pass zone to report a single zone.
+func GetRecordsLint(c *gin.Context) {
+	query := database.DB.Where("managed = ?", true)
+	if zone := c.Query("zone"); zone != "" {
+		query = query.Where("zone_name = ?", zone)
+	}
+	var records []models.DNSRecord
+	if err := scopeVisibleRecords(c, query).Order("full_domain ASC, id ASC").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+		return
+	}
+
+	zones := make(map[string]lintZone)
+	findings := []lint.Finding{}
+	errorCount, warningCount := 0, 0
+	for _, record := range records {
+		key := fmt.Sprintf("%d/%s", record.ProviderID, record.ZoneName)
+		zone, ok := zones[key]
+		if !ok {
+			var err error
+			if zone, err = loadLintZone(record.ProviderID, record.ZoneName); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch zone records"})
+				return
+			}
+			zones[key] = zone
+		}
+
+		for _, finding := range lintConfig.Check(record, zone.records, zone.limits) {
+			findings = append(findings, finding)
+			if finding.Severity == lint.SeverityError {
+				errorCount++
+			} else {
+				warningCount++
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity == lint.SeverityError && findings[j].Severity != lint.SeverityError
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"findings": findings,
+		"count":    len(findings),
+		"errors":   errorCount,
+		"warnings": warningCount,
+		"rules":    lintConfig.Describe(),
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"dnsmesh/internal/oidc"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCLogin starts the authorization-code flow and redirects to the identity provider
+func OIDCLogin(c *gin.Context) {
+	if oidc.Default == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not enabled"})
+		return
+	}
+
+	authURL, flowCookie, err := oidc.Default.BeginLogin(c.Request.Context(), c.Query("redirect"))
+	if err != nil {
+		log.Printf("OIDCLogin: Failed to start login: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to contact identity provider"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidc.FlowCookie, flowCookie, 600, "/api/auth/oidc", "", oidc.Default.SecureCookies(), true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes the login, sets the signed session cookie and
+// returns the browser to where the login started
+func OIDCCallback(c *gin.Context) {
+	if oidc.Default == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not enabled"})
+		return
+	}
+
+	if errorCode := c.Query("error"); errorCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login failed: " + errorCode + " " + c.Query("error_description")})
+		return
+	}
+
+	flowCookie, err := c.Cookie(oidc.FlowCookie)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login flow not found, please sign in again"})
+		return
+	}
+
+	session, redirect, err := oidc.Default.FinishLogin(c.Request.Context(), flowCookie, c.Query("state"), c.Query("code"))
+	if err != nil {
+		log.Printf("OIDCCallback: Login failed: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login failed: " + err.Error()})
+		return
+	}
+
+	sessionCookie, err := oidc.Default.EncodeSession(session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	secure := oidc.Default.SecureCookies()
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidc.FlowCookie, "", -1, "/api/auth/oidc", "", secure, true)
+	c.SetCookie(oidc.SessionCookie, sessionCookie, int(oidc.Default.SessionTTL().Seconds()), "/", "", secure, true)
+
+	log.Printf("OIDCCallback: User '%s' signed in (groups: %v)", session.Username, session.Groups)
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// Logout clears the OIDC session cookie and returns the provider's logout URL, if any
+func Logout(c *gin.Context) {
+	response := gin.H{"message": "Logged out"}
+	if oidc.Default != nil {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidc.SessionCookie, "", -1, "/", "", oidc.Default.SecureCookies(), true)
+		if logoutURL := oidc.Default.LogoutURL(c.Request.Context()); logoutURL != "" {
+			response["logout_url"] = logoutURL
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/propagation"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// propagationConfig is set by StartPropagationChecks
+var propagationConfig propagation.Config
+
+// StartPropagationChecks enables verification of created and updated records
+// and resumes checks that were still pending when the process stopped
+func StartPropagationChecks(cfg propagation.Config) {
+	propagationConfig = cfg
+	if !cfg.Enabled {
+		log.Println("Propagation: Checks disabled (set PROPAGATION_CHECK=true to enable)")
+		return
+	}
+
+	log.Printf("Propagation: Checking authoritative nameservers and %d resolver(s) for up to %s",
+		len(cfg.Resolvers), cfg.Timeout)
+
+	var pending []models.DNSRecord
+	if err := database.DB.Where("propagation_status = ?", propagation.StatusPending).Find(&pending).Error; err != nil {
+		log.Printf("Propagation: Failed to load pending checks: %v", err)
+		return
+	}
+	for i := range pending {
+		go runPropagationCheck(pending[i])
+	}
+}
+
+// startPropagationCheck marks record as pending and verifies it in the
+// background when propagation checks are enabled
+func startPropagationCheck(record *models.DNSRecord) {
+	if !propagationConfig.Enabled || !record.Active {
+		return
+	}
+
+	record.PropagationStatus = propagation.StatusPending
+	record.PropagationResult = ""
+	database.DB.Model(&models.DNSRecord{}).Where("id = ?", record.ID).UpdateColumns(map[string]interface{}{
+		"propagation_status": propagation.StatusPending,
+		"propagation_result": "",
+	})
+
+	go runPropagationCheck(*record)
+}
+
+// runPropagationCheck waits for record to propagate and stores the outcome,
+// unless the record changed again in the meantime
+func runPropagationCheck(record models.DNSRecord) {
+	result := propagation.Wait(context.Background(), propagationConfig, &record)
+	if !savePropagationResult(&record, result) {
+		return
+	}
+
+	log.Printf("Propagation: %s %s -> %s is %s", record.RecordType, record.FullDomain, record.TargetValue, result.Status)
+
+	publishActorEvent(systemActor("propagation"), events.RecordPropagation, gin.H{
+		"record_id": record.ID,
+		"domain":    record.FullDomain,
+		"status":    result.Status,
+		"servers":   result.Servers,
+	})
+}
+
+// savePropagationResult stores result on record if the record still has the
+// value that was checked, and reports whether it did
+func savePropagationResult(record *models.DNSRecord, result propagation.Result) bool {
+	resultJSON, _ := json.Marshal(result.Servers)
+	now := time.Now()
+
+	update := database.DB.Model(&models.DNSRecord{}).
+		Where("id = ? AND full_domain = ? AND record_type = ? AND target_value = ?",
+			record.ID, record.FullDomain, record.RecordType, record.TargetValue).
+		UpdateColumns(map[string]interface{}{
+			"propagation_status":     result.Status,
+			"propagation_result":     string(resultJSON),
+			"propagation_checked_at": now,
+		})
+	if update.Error != nil {
+		log.Printf("Propagation: Failed to save result for record %d: %v", record.ID, update.Error)
+		return false
+	}
+	if update.RowsAffected == 0 {
+		return false
+	}
+
+	record.PropagationStatus = result.Status
+	record.PropagationResult = string(resultJSON)
+	record.PropagationCheckedAt = &now
+	return true
+}
+
+// CheckRecordPropagation queries the nameservers and resolvers once for a
+// record and stores the result, whether or not automatic checks are enabled
+func CheckRecordPropagation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid record ID"})
+		return
+	}
+
+	var record models.DNSRecord
+	if err := database.DB.First(&record, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+		return
+	}
+
+	if !zoneScope(c).CanView(record.FullDomain) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+		return
+	}
+
+	result := propagation.Check(c.Request.Context(), propagationConfig, &record)
+	savePropagationResult(&record, result)
+
+	c.JSON(http.StatusOK, gin.H{
+		"record_id": record.ID,
+		"domain":    record.FullDomain,
+		"status":    result.Status,
+		"servers":   result.Servers,
+	})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
 	"dnsmesh/internal/models"
 	"dnsmesh/internal/services"
 	"dnsmesh/pkg/crypto"
@@ -111,9 +112,11 @@ func CreateProvider(c *gin.Context) {
 	log.Printf("CreateProvider: Successfully created provider ID %d", provider.ID)
 
 	// Log audit
-	logAudit(c, models.ActionCreate, models.ResourceTypeProvider, provider.ID, gin.H{
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeProvider, provider.ID, gin.H{
 		"provider_name": provider.Name,
-	})
+	}, nil, provider)
+
+	publishEvent(c, events.ProviderCreated, gin.H{"provider": provider})
 
 	provider.APIKey = ""
 	provider.APISecret = ""
@@ -145,6 +148,8 @@ func UpdateProvider(c *gin.Context) {
 		return
 	}
 
+	before := provider
+
 	// Update credentials if provided
 	if req.APIKey != "" {
 		encryptedKey, err := crypto.Encrypt(req.APIKey)
@@ -186,9 +191,12 @@ func UpdateProvider(c *gin.Context) {
 	}
 
 	// Log audit
-	logAudit(c, models.ActionUpdate, models.ResourceTypeProvider, provider.ID, gin.H{
-		"provider_name": provider.Name,
-	})
+	logAuditChange(c, models.ActionUpdate, models.ResourceTypeProvider, provider.ID, gin.H{
+		"provider_name":       provider.Name,
+		"credentials_updated": req.APIKey != "" || req.APISecret != "" || req.ExtraConfig != nil,
+	}, before, provider)
+
+	publishEvent(c, events.ProviderUpdated, gin.H{"provider": provider})
 
 	provider.APIKey = ""
 	provider.APISecret = ""
@@ -226,15 +234,21 @@ func DeleteProvider(c *gin.Context) {
 	}
 
 	// Log audit
-	logAudit(c, models.ActionDelete, models.ResourceTypeProvider, provider.ID, gin.H{
+	logAuditChange(c, models.ActionDelete, models.ResourceTypeProvider, provider.ID, gin.H{
 		"provider_name": provider.Name,
-	})
+	}, provider, nil)
+
+	publishEvent(c, events.ProviderDeleted, gin.H{"provider": provider})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Provider deleted successfully"})
 }
 
 // SyncProvider syncs DNS records from provider
 func SyncProvider(c *gin.Context) {
+	if !requireUnrestrictedZones(c) {
+		return
+	}
+
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider ID"})
@@ -270,6 +284,13 @@ func SyncProvider(c *gin.Context) {
 		"server_suggestions": len(analysis.ServerSuggestions),
 	})
 
+	publishEvent(c, events.ProviderSynced, gin.H{
+		"provider_id":        provider.ID,
+		"provider_name":      provider.Name,
+		"record_count":       len(records),
+		"server_suggestions": len(analysis.ServerSuggestions),
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"records":            analysis.Records,
 		"server_suggestions": analysis.ServerSuggestions,
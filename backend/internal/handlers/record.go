@@ -2,31 +2,36 @@ package handlers
 
 import (
 	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/lint"
 	"dnsmesh/internal/models"
 	"dnsmesh/internal/services"
-	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateRecordRequest represents the request to create a DNS record
 type CreateRecordRequest struct {
-	ProviderID   uint   `json:"provider_id"`
-	ZoneID       string `json:"zone_id"`
-	ZoneName     string `json:"zone_name"`
-	FullDomain   string `json:"full_domain" binding:"required"`
-	RecordType   string `json:"record_type" binding:"required"`
-	TargetValue  string `json:"target_value" binding:"required"`
-	TTL          int    `json:"ttl"`
-	IsServer     bool   `json:"is_server"`
-	ServerName   string `json:"server_name"`
-	ServerRegion string `json:"server_region"`
-	Notes        string `json:"notes"`
+	ProviderID   uint       `json:"provider_id"`
+	ZoneID       string     `json:"zone_id"`
+	ZoneName     string     `json:"zone_name"`
+	FullDomain   string     `json:"full_domain" binding:"required"`
+	RecordType   string     `json:"record_type" binding:"required"`
+	TargetValue  string     `json:"target_value" binding:"required"`
+	TTL          int        `json:"ttl"`
+	IsServer     bool       `json:"is_server"`
+	ServerName   string     `json:"server_name"`
+	ServerRegion string     `json:"server_region"`
+	ServerID     *uint      `json:"server_id"` // optional, linked automatically by address or hostname when unset
+	Notes        string     `json:"notes"`
+	ExpiresAt    *time.Time `json:"expires_at"` // optional, only used on create
 }
 
 // ImportRecordsRequest represents batch import request
@@ -51,16 +56,90 @@ type ImportRecordItem struct {
 
 const maxAuditRecordsPerProvider = 100
 
-// GetRecords returns all DNS records grouped by server first, then unassigned by provider
+// maxRecordsPageSize limits the limit parameter of GET /records
+const maxRecordsPageSize = 1000
+
+// GetRecords returns DNS records grouped by server first, then unassigned by
+// provider, or as a flat list with view=flat. The q parameter filters records
+// (see parseRecordQuery), sort orders them and limit/offset paginate them.
+// Without limit every matching record is returned.
 func GetRecords(c *gin.Context) {
 	var records []models.DNSRecord
 	var providers []models.Provider
 
-	// Fetch all records
-	if err := database.DB.Where("managed = ?", true).Find(&records).Error; err != nil {
+	terms, err := parseRecordQuery(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query: " + err.Error()})
+		return
+	}
+	order, err := recordOrder(c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	view := c.DefaultQuery("view", "grouped")
+	if view != "grouped" && view != "flat" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view, expected grouped or flat"})
+		return
+	}
+
+	query := scopeVisibleRecords(c, database.DB.Model(&models.DNSRecord{}).Where("managed = ?", true))
+	query, err = applyRecordQuery(query, terms)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query: " + err.Error()})
+		return
+	}
+
+	// Count all matching records before pagination is applied
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count records"})
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxRecordsPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxRecordsPageSize)})
+			return
+		}
+		query = query.Limit(limit)
+	}
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if offset, err = strconv.Atoi(offsetStr); err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+			return
+		}
+		query = query.Offset(offset)
+	}
+
+	if err := query.Order(order).Find(&records).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
 		return
 	}
+	hasMore := int64(offset+len(records)) < total
+
+	if view == "flat" {
+		c.JSON(http.StatusOK, gin.H{
+			"records":  records,
+			"count":    len(records),
+			"total":    total,
+			"has_more": hasMore,
+		})
+		return
+	}
+
+	// Filtered pages still group under their servers, so add the server
+	// records heading the groups of matched records
+	if len(terms) > 0 || limit > 0 || offset > 0 {
+		heads, err := serverGroupHeads(c, records)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch server records"})
+			return
+		}
+		records = append(records, heads...)
+	}
 
 	// Fetch all providers
 	if err := database.DB.Find(&providers).Error; err != nil {
@@ -68,12 +147,50 @@ func GetRecords(c *gin.Context) {
 		return
 	}
 
+	var servers []models.Server
+	if err := database.DB.Find(&servers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch servers"})
+		return
+	}
+
 	// Group records (server-first structure)
-	grouped := services.GroupRecords(records, providers)
+	grouped := services.GroupRecords(records, providers, servers)
+	grouped.Total = total
+	grouped.HasMore = hasMore
 
 	c.JSON(http.StatusOK, grouped)
 }
 
+// serverGroupHeads returns the visible server records of the servers records
+// are linked to that records do not already include
+func serverGroupHeads(c *gin.Context, records []models.DNSRecord) ([]models.DNSRecord, error) {
+	included := make(map[uint]bool, len(records))
+	var serverIDs []uint
+	for _, record := range records {
+		included[record.ID] = true
+		if record.ServerID != nil {
+			serverIDs = append(serverIDs, *record.ServerID)
+		}
+	}
+	if len(serverIDs) == 0 {
+		return nil, nil
+	}
+
+	var candidates []models.DNSRecord
+	if err := scopeVisibleRecords(c, database.DB.Where("managed = ? AND is_server = ? AND server_id IN ?", true, true, serverIDs)).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	heads := make([]models.DNSRecord, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !included[candidate.ID] {
+			heads = append(heads, candidate)
+		}
+	}
+	return heads, nil
+}
+
 // findZoneForDomain finds the correct zone and provider for a given domain
 func findZoneForDomain(domain string) (*models.DNSRecord, error) {
 	// Try to find an existing record with a matching zone_name
@@ -102,85 +219,39 @@ func CreateRecord(c *gin.Context) {
 	}
 
 	// Validate record type
-	if req.RecordType != models.RecordTypeA && req.RecordType != models.RecordTypeCNAME {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid record type"})
+	if err := validateRecordType(req.RecordType); err != nil {
+		respondRecordOpError(c, err)
 		return
 	}
-
-	// Auto-detect zone and provider based on domain
-	zoneRecord, err := findZoneForDomain(req.FullDomain)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot determine zone for domain: " + req.FullDomain + ". Please ensure the root domain exists in a provider."})
+	if err := validateRecordExpiry(req); err != nil {
+		respondRecordOpError(c, err)
 		return
 	}
 
-	// Use the detected provider and zone
-	providerID := zoneRecord.ProviderID
-	zoneID := zoneRecord.ZoneID
-	zoneName := zoneRecord.ZoneName
-
-	log.Printf("CreateRecord: Auto-detected zone '%s' (provider %d, zone %s) for domain '%s'",
-		zoneName, providerID, zoneID, req.FullDomain)
-
-	// Get provider
-	var provider models.Provider
-	if err := database.DB.First(&provider, providerID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
+	if !requireZoneEdit(c, req.FullDomain) {
 		return
 	}
 
-	// Default TTL
-	if req.TTL == 0 {
-		req.TTL = 600
-	}
-
-	record := models.DNSRecord{
-		ProviderID:   providerID,
-		ZoneID:       zoneID,
-		ZoneName:     zoneName,
-		FullDomain:   req.FullDomain,
-		RecordType:   req.RecordType,
-		TargetValue:  req.TargetValue,
-		TTL:          req.TTL,
-		IsServer:     req.IsServer,
-		ServerName:   req.ServerName,
-		ServerRegion: req.ServerRegion,
-		Notes:        req.Notes,
-		Active:       true,
-		Managed:      true,
-	}
-
-	// Create record on provider
-	svc, err := getProviderService(&provider)
+	findings, err := lintRequest(nil, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondLintError(c, findings, err)
 		return
 	}
 
-	providerRecordID, err := svc.CreateRecord(&record)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create record on provider: " + err.Error()})
+	if requireApproval(c, models.ChangeOpCreate, nil, req.FullDomain, req) {
 		return
 	}
 
-	record.ProviderRecordID = providerRecordID
-
-	// Save to database
-	if err := database.DB.Create(&record).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save record"})
+	record, err := createRecordOp(requestActor(c), req, nil)
+	if err != nil {
+		respondRecordOpError(c, err)
 		return
 	}
 
-	// Log audit
-	logAudit(c, models.ActionCreate, models.ResourceTypeRecord, record.ID, gin.H{
-		"domain":      record.FullDomain,
-		"record_type": record.RecordType,
-		"target":      record.TargetValue,
-	})
-
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Record created successfully",
-		"record":  record,
+		"message":  "Record created successfully",
+		"record":   record,
+		"warnings": lintWarnings(findings),
 	})
 }
 
@@ -204,65 +275,46 @@ func UpdateRecord(c *gin.Context) {
 		return
 	}
 
-	// Check if DNS-related fields have changed
-	// DNS fields that need to be synced to provider: FullDomain, RecordType, TargetValue, TTL
-	dnsFieldsChanged := record.FullDomain != req.FullDomain ||
-		record.RecordType != req.RecordType ||
-		record.TargetValue != req.TargetValue ||
-		record.TTL != req.TTL
-
-	// Update all fields (both DNS and local management fields)
-	record.FullDomain = req.FullDomain
-	record.RecordType = req.RecordType
-	record.TargetValue = req.TargetValue
-	record.TTL = req.TTL
-	record.IsServer = req.IsServer
-	record.ServerName = req.ServerName
-	record.ServerRegion = req.ServerRegion
-	record.Notes = req.Notes
-
-	// Only call provider API if DNS fields changed
-	if dnsFieldsChanged {
-		log.Printf("UpdateRecord: DNS fields changed for record %d, updating provider", record.ID)
-
-		// Get provider
-		var provider models.Provider
-		if err := database.DB.First(&provider, record.ProviderID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
-			return
-		}
+	if err := validateExpiryUpdate(&record, req); err != nil {
+		respondRecordOpError(c, err)
+		return
+	}
 
-		// Update record on provider
-		svc, err := getProviderService(&provider)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Both the current and the new name must be inside the caller's zones
+	if !requireZoneEdit(c, record.FullDomain, req.FullDomain) {
+		return
+	}
+
+	// Validate only changes that reach the provider, so metadata edits of
+	// records that break a rule (or ACME TXT records) stay possible
+	var findings []lint.Finding
+	if changesDNSFields(&record, req) {
+		if err := validateRecordType(req.RecordType); err != nil {
+			respondRecordOpError(c, err)
 			return
 		}
-
-		if err := svc.UpdateRecord(&record); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update record on provider: " + err.Error()})
+		if findings, err = lintRequest(&record, req); err != nil {
+			respondLintError(c, findings, err)
 			return
 		}
-	} else {
-		log.Printf("UpdateRecord: Only local fields changed for record %d, skipping provider update", record.ID)
 	}
 
-	// Save to database (always save, whether DNS fields changed or not)
-	if err := database.DB.Save(&record).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save record"})
+	// Metadata-only edits (notes, server name) apply directly; anything that
+	// reaches the provider or changes the server flag may need approval
+	needsReview := record.FullDomain != req.FullDomain ||
+		record.RecordType != req.RecordType ||
+		record.TargetValue != req.TargetValue ||
+		record.TTL != req.TTL ||
+		record.IsServer != req.IsServer
+	if needsReview && requireApproval(c, models.ChangeOpUpdate, &record, req.FullDomain, req) {
 		return
 	}
 
-	// Log audit
-	auditDetails := gin.H{
-		"domain":      record.FullDomain,
-		"record_type": record.RecordType,
-		"target":      record.TargetValue,
-	}
-	if !dnsFieldsChanged {
-		auditDetails["local_only"] = true
+	dnsFieldsChanged, err := updateRecordOp(requestActor(c), &record, req, nil)
+	if err != nil {
+		respondRecordOpError(c, err)
+		return
 	}
-	logAudit(c, models.ActionUpdate, models.ResourceTypeRecord, record.ID, auditDetails)
 
 	responseMessage := "Record updated successfully"
 	if !dnsFieldsChanged {
@@ -270,8 +322,9 @@ func UpdateRecord(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": responseMessage,
-		"record":  record,
+		"message":  responseMessage,
+		"record":   record,
+		"warnings": lintWarnings(findings),
 	})
 }
 
@@ -291,21 +344,14 @@ func HideRecord(c *gin.Context) {
 		return
 	}
 
-	// Soft delete: set managed = false
-	record.Managed = false
-
-	// Save to database
-	if err := database.DB.Save(&record).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hide record"})
+	if !requireZoneEdit(c, record.FullDomain) {
 		return
 	}
 
-	// Log audit
-	logAudit(c, models.ActionDelete, models.ResourceTypeRecord, record.ID, gin.H{
-		"domain":      record.FullDomain,
-		"record_type": record.RecordType,
-		"action":      "hide",
-	})
+	if err := hideRecordOp(requestActor(c), &record, nil); err != nil {
+		respondRecordOpError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Record hidden from management"})
 }
@@ -325,44 +371,25 @@ func DeleteRecord(c *gin.Context) {
 		return
 	}
 
-	// Prevent deletion of server records
-	if record.IsServer {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete server records. Use hide instead."})
-		return
-	}
-
-	// Get provider
-	var provider models.Provider
-	if err := database.DB.First(&provider, record.ProviderID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
+	if !requireZoneEdit(c, record.FullDomain) {
 		return
 	}
 
-	// Delete from provider
-	svc, err := getProviderService(&provider)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Prevent deletion of server records
+	if record.IsServer {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete server records. Use hide instead."})
 		return
 	}
 
-	if err := svc.DeleteRecord(&record); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete record from provider: " + err.Error()})
+	if requireApproval(c, models.ChangeOpDelete, &record, record.FullDomain, nil) {
 		return
 	}
 
-	// Delete from database
-	if err := database.DB.Delete(&record).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete record"})
+	if err := deleteRecordOp(requestActor(c), &record, nil); err != nil {
+		respondRecordOpError(c, err)
 		return
 	}
 
-	// Log audit
-	logAudit(c, models.ActionDelete, models.ResourceTypeRecord, record.ID, gin.H{
-		"domain":      record.FullDomain,
-		"record_type": record.RecordType,
-		"action":      "delete",
-	})
-
 	c.JSON(http.StatusOK, gin.H{"message": "Record deleted successfully"})
 }
 
@@ -389,6 +416,10 @@ func toggleRecordStatus(c *gin.Context, enabled bool) {
 		return
 	}
 
+	if !requireZoneEdit(c, record.FullDomain) {
+		return
+	}
+
 	if record.ProviderRecordID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Record has no provider reference"})
 		return
@@ -406,46 +437,22 @@ func toggleRecordStatus(c *gin.Context, enabled bool) {
 		return
 	}
 
-	var provider models.Provider
-	if err := database.DB.First(&provider, record.ProviderID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
-		return
-	}
-
-	svc, err := getProviderService(&provider)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	operation := models.ChangeOpEnable
+	message := "Record enabled successfully"
+	if !enabled {
+		operation = models.ChangeOpDisable
+		message = "Record disabled successfully"
 	}
 
-	if err := svc.SetRecordStatus(&record, enabled); err != nil {
-		if errors.Is(err, services.ErrRecordStatusNotSupported) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "当前 DNS 提供商暂不支持暂停解析记录"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update provider record status: " + err.Error()})
+	if requireApproval(c, operation, &record, record.FullDomain, nil) {
 		return
 	}
 
-	record.Active = enabled
-	if err := database.DB.Save(&record).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update record status"})
+	if _, err := setRecordStatusOp(requestActor(c), &record, enabled, nil); err != nil {
+		respondRecordOpError(c, err)
 		return
 	}
 
-	action := "enable"
-	message := "Record enabled successfully"
-	if !enabled {
-		action = "disable"
-		message = "Record disabled successfully"
-	}
-
-	logAudit(c, models.ActionUpdate, models.ResourceTypeRecord, record.ID, gin.H{
-		"domain":      record.FullDomain,
-		"record_type": record.RecordType,
-		"action":      action,
-	})
-
 	c.JSON(http.StatusOK, gin.H{
 		"message": message,
 		"record":  record,
@@ -463,6 +470,12 @@ func ImportRecords(c *gin.Context) {
 
 	log.Printf("ImportRecords: Received request to import %d records for provider %d", len(req.Records), req.ProviderID)
 
+	for _, item := range req.Records {
+		if !requireZoneEdit(c, item.FullDomain) {
+			return
+		}
+	}
+
 	// Get provider
 	var provider models.Provider
 	if err := database.DB.First(&provider, req.ProviderID).Error; err != nil {
@@ -502,21 +515,46 @@ func ImportRecords(c *gin.Context) {
 
 	log.Printf("ImportRecords: Successfully imported %d records, failed %d", len(imported), failed)
 
+	if _, _, err := linkServerRecords(); err != nil {
+		log.Printf("ImportRecords: Failed to link records to servers: %v", err)
+	}
+
 	// Log audit
 	logAudit(c, models.ActionCreate, models.ResourceTypeRecord, 0, gin.H{
 		"action": "batch_import",
 		"count":  len(imported),
 	})
 
+	for _, record := range imported {
+		publishEvent(c, events.RecordCreated, gin.H{"record": record, "source": "import"})
+	}
+
+	// Imported records already exist at the provider, so lint findings are
+	// reported without blocking the import
+	findings := []lint.Finding{}
+	for _, record := range imported {
+		recordFindings, err := lintRecord(record)
+		if err != nil {
+			log.Printf("ImportRecords: Failed to lint record %s: %v", record.FullDomain, err)
+			continue
+		}
+		findings = append(findings, recordFindings...)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Records imported successfully",
 		"count":   len(imported),
 		"records": imported,
+		"lint":    findings,
 	})
 }
 
 // ReanalyzeRecords re-syncs all providers and re-analyzes all records
 func ReanalyzeRecords(c *gin.Context) {
+	if !requireUnrestrictedZones(c) {
+		return
+	}
+
 	log.Println("ReanalyzeRecords: Starting re-analysis...")
 
 	// Get all providers
@@ -542,6 +580,10 @@ func ReanalyzeRecords(c *gin.Context) {
 	providerStats := make(map[uint]*providerSyncSummary)
 	syncedRecordIDs := make(map[uint]map[string]struct{})
 
+	// Drift: managed records changed or removed at the provider outside dnsMesh
+	driftChanged := make(map[uint][]gin.H)
+	driftMissing := make(map[uint][]gin.H)
+
 	// Collect all records from all providers with provider ID tracking
 	type RecordWithProvider struct {
 		ProviderID uint
@@ -633,6 +675,7 @@ func ReanalyzeRecords(c *gin.Context) {
 			// Update existing record
 			// Check if record was previously hidden (managed = false)
 			wasHidden := !record.Managed
+			previous := record
 			contentChanged := record.TargetValue != rwp.Record.TargetValue ||
 				record.TTL != rwp.Record.TTL ||
 				record.ZoneName != rwp.Record.ZoneName ||
@@ -669,6 +712,15 @@ func ReanalyzeRecords(c *gin.Context) {
 				continue
 			}
 
+			if !wasHidden && contentChanged {
+				driftChanged[rwp.ProviderID] = append(driftChanged[rwp.ProviderID], gin.H{
+					"record_id":   record.ID,
+					"domain":      record.FullDomain,
+					"record_type": record.RecordType,
+					"changes":     diffAuditSnapshots(previous, record),
+				})
+			}
+
 			if wasHidden && !contentChanged {
 				summary.KeptHidden++
 			} else {
@@ -719,26 +771,34 @@ func ReanalyzeRecords(c *gin.Context) {
 			ids = append(ids, id)
 		}
 
+		// TXT challenge records are never returned by a sync, so they cannot go missing
 		sampleQuery := database.DB.Model(&models.DNSRecord{}).
 			Where("provider_id = ? AND managed = ?", providerID, true).
-			Where("provider_record_id <> ''")
+			Where("provider_record_id <> '' AND record_type <> ?", models.RecordTypeTXT)
 		updateQuery := database.DB.Model(&models.DNSRecord{}).
 			Where("provider_id = ? AND managed = ?", providerID, true).
-			Where("provider_record_id <> ''")
+			Where("provider_record_id <> '' AND record_type <> ?", models.RecordTypeTXT)
 
 		if len(ids) > 0 {
 			sampleQuery = sampleQuery.Where("provider_record_id NOT IN ?", ids)
 			updateQuery = updateQuery.Where("provider_record_id NOT IN ?", ids)
 		}
 
-		var missingSample []models.DNSRecord
+		var missingRecords []models.DNSRecord
 		if err := sampleQuery.
-			Select("id", "full_domain", "record_type", "provider_record_id").
-			Limit(10).
-			Find(&missingSample).Error; err == nil && len(missingSample) > 0 {
+			Select("id", "full_domain", "record_type", "target_value", "provider_record_id").
+			Find(&missingRecords).Error; err == nil && len(missingRecords) > 0 {
 			var details []string
-			for _, rec := range missingSample {
-				details = append(details, fmt.Sprintf("%s:%s(%s)", rec.FullDomain, rec.RecordType, rec.ProviderRecordID))
+			for i, rec := range missingRecords {
+				if i < 10 {
+					details = append(details, fmt.Sprintf("%s:%s(%s)", rec.FullDomain, rec.RecordType, rec.ProviderRecordID))
+				}
+				driftMissing[providerID] = append(driftMissing[providerID], gin.H{
+					"record_id":   rec.ID,
+					"domain":      rec.FullDomain,
+					"record_type": rec.RecordType,
+					"target":      rec.TargetValue,
+				})
 			}
 			log.Printf(
 				"ReanalyzeRecords: Missing records sample for provider %d: %s",
@@ -800,10 +860,34 @@ func ReanalyzeRecords(c *gin.Context) {
 
 	log.Printf("ReanalyzeRecords: Updated %d records as servers", updated)
 
+	if _, _, err := linkServerRecords(); err != nil {
+		log.Printf("ReanalyzeRecords: Failed to link records to servers: %v", err)
+	}
+
 	providerSummaries := make([]providerSyncSummary, 0, len(providers))
 	for _, provider := range providers {
 		if summary, ok := providerStats[provider.ID]; ok {
 			providerSummaries = append(providerSummaries, *summary)
+
+			publishEvent(c, events.ProviderSynced, gin.H{
+				"provider_id":   summary.ProviderID,
+				"provider_name": summary.ProviderName,
+				"synced":        summary.Synced,
+				"created":       summary.Created,
+				"updated":       summary.Updated,
+				"reimported":    summary.Reimported,
+				"kept_hidden":   summary.KeptHidden,
+				"errors":        summary.Errors,
+			})
+		}
+
+		if len(driftChanged[provider.ID]) > 0 || len(driftMissing[provider.ID]) > 0 {
+			publishEvent(c, events.DriftDetected, gin.H{
+				"provider_id":   provider.ID,
+				"provider_name": provider.Name,
+				"changed":       driftChanged[provider.ID],
+				"missing":       driftMissing[provider.ID],
+			})
 		}
 	}
 
@@ -816,6 +900,8 @@ func ReanalyzeRecords(c *gin.Context) {
 		"providers":      providerSummaries,
 	})
 
+	checkDanglingAfterSync()
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Re-analysis completed",
 		"suggestions":  len(result.ServerSuggestions),
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchOperations limits the size of a records batch
+const maxBatchOperations = 100
+
+// BatchRecordOperation is one operation of a records batch
+type BatchRecordOperation struct {
+	Op       string               `json:"op" binding:"required"` // create, update, delete, hide, enable or disable
+	RecordID uint                 `json:"record_id"`             // record to change, all but create
+	Record   *CreateRecordRequest `json:"record"`                // new record for create, desired record for update
+}
+
+// BatchRecordsRequest represents the request to change several records at once
+type BatchRecordsRequest struct {
+	Operations []BatchRecordOperation `json:"operations" binding:"required"`
+	DryRun     bool                   `json:"dry_run"` // only validate and return the planned batch
+}
+
+// BatchRecords applies several record operations as one batch. Every
+// operation is validated before any provider is called; operations then run
+// provider by provider, in request order within each provider, and a failure
+// rolls back the operations already applied. Results are in request order.
+func BatchRecords(c *gin.Context) {
+	var req BatchRecordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Operations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No operations given"})
+		return
+	}
+	if len(req.Operations) > maxBatchOperations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d operations per batch", maxBatchOperations)})
+		return
+	}
+
+	ops := make([]batchOp, len(req.Operations))
+	seen := make(map[uint]int)
+	for i, operation := range req.Operations {
+		// The route only requires records:write; deletes, hides and toggles
+		// need the same permission as their single-record endpoints
+		if permission := batchOpPermission(operation.Op); permission != "" && !hasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Operation %d: permission denied: %s requires '%s'", i, operation.Op, permission)})
+			return
+		}
+
+		op, err := validateBatchOperation(operation)
+		if err != nil {
+			var opErr *recordOpError
+			if errors.As(err, &opErr) {
+				c.JSON(opErr.Status, gin.H{"error": fmt.Sprintf("Operation %d: %s", i, opErr.Message)})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if operation.Op != models.ChangeOpCreate {
+			if j, dup := seen[operation.RecordID]; dup {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Operation %d: record %d is already changed by operation %d", i, operation.RecordID, j)})
+				return
+			}
+			seen[operation.RecordID] = i
+		}
+
+		domains := []string{op.Record.FullDomain}
+		if operation.Op == models.ChangeOpUpdate {
+			domains = append(domains, op.Request.FullDomain)
+		}
+		if !requireZoneEdit(c, domains...) {
+			return
+		}
+
+		ops[i] = op
+	}
+
+	// Run the operations of each provider together, providers in order of
+	// first appearance; order maps positions in ops back to the request
+	order := make([]int, len(ops))
+	providerRank := make(map[uint]int)
+	for i := range ops {
+		order[i] = i
+		if _, ok := providerRank[ops[i].Record.ProviderID]; !ok {
+			providerRank[ops[i].Record.ProviderID] = len(providerRank)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return providerRank[ops[order[a]].Record.ProviderID] < providerRank[ops[order[b]].Record.ProviderID]
+	})
+	sorted := make([]batchOp, len(ops))
+	for i, index := range order {
+		sorted[i] = ops[index]
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{"batch": requestOrderBatch(planBatch(sorted), order)})
+		return
+	}
+
+	reason, err := batchApprovalReason(sorted)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reason != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Batch touches records that require approval (" + reason + "), change them individually"})
+		return
+	}
+
+	result := requestOrderBatch(runBatch(requestActor(c), sorted, nil), order)
+	if result.Status != batchStatusApplied {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Batch failed, applied operations were rolled back",
+			"batch": result,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Batch applied successfully",
+		"batch":   result,
+	})
+}
+
+// validateBatchOperation checks one operation of a records batch the way the
+// single-record handlers do and turns it into a batch op. Records are linted
+// against the zone as it is before the batch.
+func validateBatchOperation(operation BatchRecordOperation) (batchOp, error) {
+	if operation.Op == models.ChangeOpCreate {
+		if operation.Record == nil {
+			return batchOp{}, newRecordOpError(http.StatusBadRequest, "record is required")
+		}
+		req := *operation.Record
+		if err := validateBatchRecordRequest(req); err != nil {
+			return batchOp{}, err
+		}
+		if err := validateRecordExpiry(req); err != nil {
+			return batchOp{}, err
+		}
+		zoneRecord, err := findZoneForDomain(req.FullDomain)
+		if err != nil {
+			return batchOp{}, newRecordOpError(http.StatusBadRequest, "Cannot determine zone for domain: "+req.FullDomain)
+		}
+		if _, err := lintRequest(nil, req); err != nil {
+			return batchOp{}, err
+		}
+
+		// Stands in for the record until it is created
+		record := &models.DNSRecord{
+			ProviderID: zoneRecord.ProviderID,
+			ZoneName:   zoneRecord.ZoneName,
+			FullDomain: req.FullDomain,
+			RecordType: req.RecordType,
+		}
+		return batchOp{Op: models.ChangeOpCreate, Record: record, Request: req}, nil
+	}
+
+	if operation.RecordID == 0 {
+		return batchOp{}, newRecordOpError(http.StatusBadRequest, "record_id is required")
+	}
+	var record models.DNSRecord
+	if err := database.DB.Where("id = ? AND managed = ?", operation.RecordID, true).First(&record).Error; err != nil {
+		return batchOp{}, newRecordOpError(http.StatusNotFound, fmt.Sprintf("Record %d not found", operation.RecordID))
+	}
+
+	op := batchOp{Op: operation.Op, Record: &record}
+	switch operation.Op {
+	case models.ChangeOpUpdate:
+		if operation.Record == nil {
+			return batchOp{}, newRecordOpError(http.StatusBadRequest, "record is required")
+		}
+		req := *operation.Record
+		if err := validateBatchRecordRequest(req); err != nil {
+			return batchOp{}, err
+		}
+		if err := validateExpiryUpdate(&record, req); err != nil {
+			return batchOp{}, err
+		}
+		if changesDNSFields(&record, req) {
+			if _, err := lintRequest(&record, req); err != nil {
+				return batchOp{}, err
+			}
+		}
+		op.Request = req
+	case models.ChangeOpDelete:
+		if record.IsServer {
+			return batchOp{}, newRecordOpError(http.StatusBadRequest, "Cannot delete server records. Use hide instead.")
+		}
+	case batchOpHide:
+	case models.ChangeOpEnable, models.ChangeOpDisable:
+		if record.ProviderRecordID == "" {
+			return batchOp{}, newRecordOpError(http.StatusBadRequest, "Record has no provider reference")
+		}
+	default:
+		return batchOp{}, newRecordOpError(http.StatusBadRequest, "Invalid op "+operation.Op)
+	}
+	return op, nil
+}
+
+// batchOpPermission returns the permission a batch operation needs, or "" for
+// unknown operations
+func batchOpPermission(op string) string {
+	if op == batchOpHide {
+		return auth.PermRecordsDelete
+	}
+	return changeOpPermissions[op]
+}
+
+// validateBatchRecordRequest checks the fields CreateRecordRequest binding
+// would require, plus the record type and server
+func validateBatchRecordRequest(req CreateRecordRequest) error {
+	if req.FullDomain == "" || req.RecordType == "" || req.TargetValue == "" {
+		return newRecordOpError(http.StatusBadRequest, "full_domain, record_type and target_value are required")
+	}
+	if err := validateRecordType(req.RecordType); err != nil {
+		return err
+	}
+	return validateServerID(req.ServerID)
+}
+
+// requestOrderBatch puts the results of a batch run in provider order back
+// into request order
+func requestOrderBatch(result BatchResult, order []int) BatchResult {
+	results := make([]BatchOpResult, len(result.Results))
+	for i, opResult := range result.Results {
+		opResult.Index = order[i]
+		results[order[i]] = opResult
+	}
+	result.Results = results
+	return result
+}
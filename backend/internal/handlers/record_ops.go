@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/services"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Record operations talk to the DNS provider, persist the result and record
+// audit entries and events. They are shared by the HTTP handlers, approved
+// change requests and background jobs, which is why they take an actor
+// instead of a request context.
+
+// recordOpError is a failed record operation together with the HTTP status to report
+type recordOpError struct {
+	Status  int
+	Message string
+}
+
+func (e *recordOpError) Error() string {
+	return e.Message
+}
+
+func newRecordOpError(status int, message string) error {
+	return &recordOpError{Status: status, Message: message}
+}
+
+// respondRecordOpError writes err as a JSON error response
+func respondRecordOpError(c *gin.Context, err error) {
+	var opErr *recordOpError
+	if errors.As(err, &opErr) {
+		c.JSON(opErr.Status, gin.H{"error": opErr.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// mergeDetails adds extra audit details (e.g. the approving change request) to details
+func mergeDetails(details, extra gin.H) gin.H {
+	for key, value := range extra {
+		details[key] = value
+	}
+	return details
+}
+
+// validateRecordType rejects record types that cannot be managed
+func validateRecordType(recordType string) error {
+	if recordType != models.RecordTypeA && recordType != models.RecordTypeAAAA && recordType != models.RecordTypeCNAME {
+		return newRecordOpError(http.StatusBadRequest, "Invalid record type")
+	}
+	return nil
+}
+
+// validateRecordExpiry checks the optional expiry of a new record. Ephemeral
+// records are removed by the reaper, which cannot delete server records.
+func validateRecordExpiry(req CreateRecordRequest) error {
+	if req.ExpiresAt == nil {
+		return nil
+	}
+	if req.IsServer {
+		return newRecordOpError(http.StatusBadRequest, "Server records cannot expire")
+	}
+	if !req.ExpiresAt.After(time.Now()) {
+		return newRecordOpError(http.StatusBadRequest, "expires_at must be in the future")
+	}
+	return nil
+}
+
+// changesDNSFields reports whether req changes fields of record that reach the provider
+func changesDNSFields(record *models.DNSRecord, req CreateRecordRequest) bool {
+	return record.FullDomain != req.FullDomain ||
+		record.RecordType != req.RecordType ||
+		record.TargetValue != req.TargetValue ||
+		record.TTL != req.TTL
+}
+
+// validateExpiryUpdate keeps expiring records from becoming server records,
+// which the reaper cannot delete
+func validateExpiryUpdate(record *models.DNSRecord, req CreateRecordRequest) error {
+	if req.IsServer && !record.IsServer && record.ExpiresAt != nil {
+		return newRecordOpError(http.StatusBadRequest, "Expiring records cannot become server records")
+	}
+	return nil
+}
+
+// recordRequestFrom is an update request that leaves record as it is
+func recordRequestFrom(record *models.DNSRecord) CreateRecordRequest {
+	return CreateRecordRequest{
+		FullDomain:   record.FullDomain,
+		RecordType:   record.RecordType,
+		TargetValue:  record.TargetValue,
+		TTL:          record.TTL,
+		IsServer:     record.IsServer,
+		ServerName:   record.ServerName,
+		ServerRegion: record.ServerRegion,
+		ServerID:     record.ServerID,
+		Notes:        record.Notes,
+	}
+}
+
+// recordRequestWithTarget is an update request that only changes record's target
+func recordRequestWithTarget(record *models.DNSRecord, target string) CreateRecordRequest {
+	req := recordRequestFrom(record)
+	req.TargetValue = target
+	return req
+}
+
+// providerServiceFor loads a record's provider and returns its API client
+func providerServiceFor(providerID uint) (services.DNSProvider, error) {
+	var provider models.Provider
+	if err := database.DB.First(&provider, providerID).Error; err != nil {
+		return nil, newRecordOpError(http.StatusNotFound, "Provider not found")
+	}
+
+	svc, err := getProviderService(&provider)
+	if err != nil {
+		return nil, newRecordOpError(http.StatusInternalServerError, err.Error())
+	}
+	return svc, nil
+}
+
+// createRecordOp creates a record at the provider of its auto-detected zone and
+// saves it. Callers validate the record type: users may only create A, AAAA
+// and CNAME records, while the ACME endpoints create TXT challenges.
+func createRecordOp(actor auditActor, req CreateRecordRequest, extra gin.H) (*models.DNSRecord, error) {
+	// Auto-detect zone and provider based on domain
+	zoneRecord, err := findZoneForDomain(req.FullDomain)
+	if err != nil {
+		return nil, newRecordOpError(http.StatusBadRequest, "Cannot determine zone for domain: "+req.FullDomain+". Please ensure the root domain exists in a provider.")
+	}
+
+	log.Printf("CreateRecord: Auto-detected zone '%s' (provider %d, zone %s) for domain '%s'",
+		zoneRecord.ZoneName, zoneRecord.ProviderID, zoneRecord.ZoneID, req.FullDomain)
+
+	svc, err := providerServiceFor(zoneRecord.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRecordExpiry(req); err != nil {
+		return nil, err
+	}
+	if err := validateServerID(req.ServerID); err != nil {
+		return nil, err
+	}
+
+	// Default TTL
+	if req.TTL == 0 {
+		req.TTL = 600
+	}
+
+	record := models.DNSRecord{
+		ProviderID:   zoneRecord.ProviderID,
+		ZoneID:       zoneRecord.ZoneID,
+		ZoneName:     zoneRecord.ZoneName,
+		FullDomain:   req.FullDomain,
+		RecordType:   req.RecordType,
+		TargetValue:  req.TargetValue,
+		TTL:          req.TTL,
+		IsServer:     req.IsServer,
+		ServerName:   req.ServerName,
+		ServerRegion: req.ServerRegion,
+		ServerID:     req.ServerID,
+		Notes:        req.Notes,
+		Active:       true,
+		Managed:      true,
+	}
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		record.ExpiresAt = &expiresAt
+	}
+
+	providerRecordID, err := svc.CreateRecord(&record)
+	if err != nil {
+		return nil, newRecordOpError(http.StatusInternalServerError, "Failed to create record on provider: "+err.Error())
+	}
+	record.ProviderRecordID = providerRecordID
+
+	if err := database.DB.Create(&record).Error; err != nil {
+		return nil, newRecordOpError(http.StatusInternalServerError, "Failed to save record")
+	}
+	assignServer(&record)
+
+	details := gin.H{
+		"domain":      record.FullDomain,
+		"record_type": record.RecordType,
+		"target":      record.TargetValue,
+	}
+	if record.ExpiresAt != nil {
+		details["expires_at"] = record.ExpiresAt
+	}
+	logActorAuditChange(actor, models.ActionCreate, models.ResourceTypeRecord, record.ID, mergeDetails(details, extra), nil, record)
+
+	publishActorEvent(actor, events.RecordCreated, gin.H{"record": record})
+
+	startPropagationCheck(&record)
+
+	return &record, nil
+}
+
+// updateRecordOp applies req to record, calling the provider only when DNS
+// fields change. It reports whether the provider was updated.
+func updateRecordOp(actor auditActor, record *models.DNSRecord, req CreateRecordRequest, extra gin.H) (bool, error) {
+	if err := validateServerID(req.ServerID); err != nil {
+		return false, err
+	}
+	if err := validateExpiryUpdate(record, req); err != nil {
+		return false, err
+	}
+
+	before := *record
+
+	// Check if DNS-related fields have changed
+	// DNS fields that need to be synced to provider: FullDomain, RecordType, TargetValue, TTL
+	dnsFieldsChanged := record.FullDomain != req.FullDomain ||
+		record.RecordType != req.RecordType ||
+		record.TargetValue != req.TargetValue ||
+		record.TTL != req.TTL
+
+	// Update all fields (both DNS and local management fields)
+	record.FullDomain = req.FullDomain
+	record.RecordType = req.RecordType
+	record.TargetValue = req.TargetValue
+	record.TTL = req.TTL
+	record.IsServer = req.IsServer
+	record.ServerName = req.ServerName
+	record.ServerRegion = req.ServerRegion
+	record.Notes = req.Notes
+	if req.ServerID != nil {
+		record.ServerID = req.ServerID
+	}
+
+	// Only call provider API if DNS fields changed
+	if dnsFieldsChanged {
+		log.Printf("UpdateRecord: DNS fields changed for record %d, updating provider", record.ID)
+
+		svc, err := providerServiceFor(record.ProviderID)
+		if err != nil {
+			*record = before
+			return false, err
+		}
+
+		if err := svc.UpdateRecord(record); err != nil {
+			*record = before
+			return false, newRecordOpError(http.StatusInternalServerError, "Failed to update record on provider: "+err.Error())
+		}
+	} else {
+		log.Printf("UpdateRecord: Only local fields changed for record %d, skipping provider update", record.ID)
+	}
+
+	// Save to database (always save, whether DNS fields changed or not)
+	if err := database.DB.Save(record).Error; err != nil {
+		return dnsFieldsChanged, newRecordOpError(http.StatusInternalServerError, "Failed to save record")
+	}
+	assignServer(record)
+
+	auditDetails := gin.H{
+		"domain":      record.FullDomain,
+		"record_type": record.RecordType,
+		"target":      record.TargetValue,
+	}
+	if !dnsFieldsChanged {
+		auditDetails["local_only"] = true
+	}
+	logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeRecord, record.ID, mergeDetails(auditDetails, extra), before, *record)
+
+	publishActorEvent(actor, events.RecordUpdated, gin.H{
+		"record":     *record,
+		"changes":    diffAuditSnapshots(before, *record),
+		"local_only": !dnsFieldsChanged,
+	})
+
+	if dnsFieldsChanged {
+		startPropagationCheck(record)
+	}
+
+	return dnsFieldsChanged, nil
+}
+
+// deleteRecordOp deletes a non-server record from the provider and the database
+func deleteRecordOp(actor auditActor, record *models.DNSRecord, extra gin.H) error {
+	// Prevent deletion of server records
+	if record.IsServer {
+		return newRecordOpError(http.StatusBadRequest, "Cannot delete server records. Use hide instead.")
+	}
+
+	return removeRecordOp(actor, record, extra)
+}
+
+// removeRecordOp deletes any record, server records included, from the
+// provider and the database. Only decommissioning a server removes its
+// server records this way.
+func removeRecordOp(actor auditActor, record *models.DNSRecord, extra gin.H) error {
+	svc, err := providerServiceFor(record.ProviderID)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.DeleteRecord(record); err != nil {
+		return newRecordOpError(http.StatusInternalServerError, "Failed to delete record from provider: "+err.Error())
+	}
+
+	if err := database.DB.Delete(record).Error; err != nil {
+		return newRecordOpError(http.StatusInternalServerError, "Failed to delete record")
+	}
+
+	logActorAuditChange(actor, models.ActionDelete, models.ResourceTypeRecord, record.ID, mergeDetails(gin.H{
+		"domain":      record.FullDomain,
+		"record_type": record.RecordType,
+		"action":      "delete",
+	}, extra), *record, nil)
+
+	publishActorEvent(actor, events.RecordDeleted, gin.H{"record": *record})
+
+	return nil
+}
+
+// hideRecordOp removes a record from management without touching the provider
+func hideRecordOp(actor auditActor, record *models.DNSRecord, extra gin.H) error {
+	before := *record
+
+	// Soft delete: set managed = false
+	record.Managed = false
+	if err := database.DB.Save(record).Error; err != nil {
+		*record = before
+		return newRecordOpError(http.StatusInternalServerError, "Failed to hide record")
+	}
+
+	logActorAuditChange(actor, models.ActionDelete, models.ResourceTypeRecord, record.ID, mergeDetails(gin.H{
+		"domain":      record.FullDomain,
+		"record_type": record.RecordType,
+		"action":      "hide",
+	}, extra), before, *record)
+
+	publishActorEvent(actor, events.RecordHidden, gin.H{"record": *record})
+
+	return nil
+}
+
+// setRecordStatusOp enables or disables a record at the provider. It reports
+// false when the record was already in the requested state.
+func setRecordStatusOp(actor auditActor, record *models.DNSRecord, enabled bool, extra gin.H) (bool, error) {
+	if record.ProviderRecordID == "" {
+		return false, newRecordOpError(http.StatusBadRequest, "Record has no provider reference")
+	}
+
+	if record.Active == enabled {
+		return false, nil
+	}
+
+	svc, err := providerServiceFor(record.ProviderID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := svc.SetRecordStatus(record, enabled); err != nil {
+		if errors.Is(err, services.ErrRecordStatusNotSupported) {
+			return false, newRecordOpError(http.StatusBadRequest, "当前 DNS 提供商暂不支持暂停解析记录")
+		}
+		return false, newRecordOpError(http.StatusInternalServerError, "Failed to update provider record status: "+err.Error())
+	}
+
+	before := *record
+	record.Active = enabled
+	if err := database.DB.Save(record).Error; err != nil {
+		return false, newRecordOpError(http.StatusInternalServerError, "Failed to update record status")
+	}
+
+	action := "enable"
+	eventType := events.RecordEnabled
+	if !enabled {
+		action = "disable"
+		eventType = events.RecordDisabled
+	}
+
+	logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeRecord, record.ID, mergeDetails(gin.H{
+		"domain":      record.FullDomain,
+		"record_type": record.RecordType,
+		"action":      action,
+	}, extra), before, *record)
+
+	publishActorEvent(actor, eventType, gin.H{"record": *record})
+
+	return true, nil
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Record queries filter GET /api/records with terms like
+//
+//	type:CNAME zone:example.com target:*.cdn.net server:hk-* notes:"legacy" active:false
+//
+// Terms are ANDed; comma-separated values of a term are ORed and a leading
+// "-" negates a term. Values match whole and case-insensitively, with * and ?
+// as wildcards; notes and bare words match anywhere. Quoted values may
+// contain spaces and commas.
+
+// recordQueryTerm is one parsed term of a record query
+type recordQueryTerm struct {
+	Key    string // empty for bare words
+	Values []string
+	Negate bool
+}
+
+// maxRecordQueryTerms limits the size of a record query
+const maxRecordQueryTerms = 20
+
+// recordQueryKeys are the keys a record query accepts
+var recordQueryKeys = map[string]bool{
+	"type":      true,
+	"zone":      true,
+	"domain":    true,
+	"target":    true,
+	"server":    true,
+	"region":    true,
+	"provider":  true,
+	"notes":     true,
+	"active":    true,
+	"is_server": true,
+	"ttl":       true,
+}
+
+// recordSortFields are the columns records can be sorted by
+var recordSortFields = map[string]bool{
+	"id":           true,
+	"full_domain":  true,
+	"zone_name":    true,
+	"record_type":  true,
+	"target_value": true,
+	"ttl":          true,
+	"created_at":   true,
+	"updated_at":   true,
+}
+
+// parseRecordQuery splits a record query into terms
+func parseRecordQuery(q string) ([]recordQueryTerm, error) {
+	var terms []recordQueryTerm
+	rest := strings.TrimSpace(q)
+	for rest != "" {
+		token, quoted, remaining, err := nextQueryToken(rest)
+		if err != nil {
+			return nil, err
+		}
+		rest = strings.TrimSpace(remaining)
+
+		term := recordQueryTerm{}
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			term.Negate = true
+			token = token[1:]
+		}
+
+		value := token
+		if key, v, found := strings.Cut(token, ":"); found && !quoted.beforeColon {
+			term.Key = strings.ToLower(key)
+			value = v
+			if !recordQueryKeys[term.Key] {
+				return nil, fmt.Errorf("unknown filter %q", key)
+			}
+		}
+
+		if quoted.value {
+			term.Values = []string{value}
+		} else {
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					term.Values = append(term.Values, v)
+				}
+			}
+		}
+		if len(term.Values) == 0 {
+			return nil, fmt.Errorf("filter %q has no value", token)
+		}
+
+		terms = append(terms, term)
+		if len(terms) > maxRecordQueryTerms {
+			return nil, fmt.Errorf("at most %d filters per query", maxRecordQueryTerms)
+		}
+	}
+	return terms, nil
+}
+
+// queryTokenQuotes tells where a token contained double quotes
+type queryTokenQuotes struct {
+	beforeColon bool // a bare quoted word, never a key:value pair
+	value       bool
+}
+
+// nextQueryToken reads one whitespace-separated token from s, removing the
+// double quotes around quoted parts
+func nextQueryToken(s string) (string, queryTokenQuotes, string, error) {
+	var token strings.Builder
+	var quotes queryTokenQuotes
+	seenColon := false
+	inQuotes := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			if seenColon {
+				quotes.value = true
+			} else {
+				quotes.beforeColon = true
+			}
+		case r == ':' && !inQuotes && !seenColon:
+			seenColon = true
+			token.WriteRune(r)
+		case (r == ' ' || r == '\t') && !inQuotes:
+			return token.String(), quotes, s[i:], nil
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return "", quotes, "", fmt.Errorf("unterminated quote in query")
+	}
+	return token.String(), quotes, "", nil
+}
+
+// applyRecordQuery adds the terms of a record query to query
+func applyRecordQuery(query *gorm.DB, terms []recordQueryTerm) (*gorm.DB, error) {
+	for _, term := range terms {
+		condition := database.DB.Where("1 = 0")
+		for _, value := range term.Values {
+			expr, args, err := recordQueryCondition(term.Key, value)
+			if err != nil {
+				return nil, err
+			}
+			condition = condition.Or(expr, args...)
+		}
+		if term.Negate {
+			query = query.Not(condition)
+		} else {
+			query = query.Where(condition)
+		}
+	}
+	return query, nil
+}
+
+// recordQueryCondition returns the SQL condition matching one value of a term
+func recordQueryCondition(key, value string) (string, []interface{}, error) {
+	pattern := globToLike(value)
+	contains := "%" + pattern + "%"
+
+	switch key {
+	case "":
+		return "full_domain LIKE ? ESCAPE '\\'", []interface{}{contains}, nil
+	case "type":
+		return "record_type LIKE ? ESCAPE '\\'", []interface{}{pattern}, nil
+	case "zone":
+		return "zone_name LIKE ? ESCAPE '\\'", []interface{}{pattern}, nil
+	case "domain":
+		return "full_domain LIKE ? ESCAPE '\\'", []interface{}{pattern}, nil
+	case "target":
+		return "target_value LIKE ? ESCAPE '\\'", []interface{}{pattern}, nil
+	case "notes":
+		return "notes LIKE ? ESCAPE '\\'", []interface{}{contains}, nil
+	case "server":
+		servers := database.DB.Model(&models.Server{}).Select("id").Where("name LIKE ? ESCAPE '\\'", pattern)
+		return "server_name LIKE ? ESCAPE '\\' OR (server_id IS NOT NULL AND server_id IN (?))", []interface{}{pattern, servers}, nil
+	case "region":
+		servers := database.DB.Model(&models.Server{}).Select("id").Where("region LIKE ? ESCAPE '\\'", pattern)
+		return "server_region LIKE ? ESCAPE '\\' OR (server_id IS NOT NULL AND server_id IN (?))", []interface{}{pattern, servers}, nil
+	case "provider":
+		if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+			return "provider_id = ?", []interface{}{id}, nil
+		}
+		providers := database.DB.Model(&models.Provider{}).Select("id").Where("name LIKE ? ESCAPE '\\'", pattern)
+		return "provider_id IN (?)", []interface{}{providers}, nil
+	case "active", "is_server":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s expects true or false, got %q", key, value)
+		}
+		return key + " = ?", []interface{}{enabled}, nil
+	case "ttl":
+		ttl, err := strconv.Atoi(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("ttl expects a number, got %q", value)
+		}
+		return "ttl = ?", []interface{}{ttl}, nil
+	}
+	return "", nil, fmt.Errorf("unknown filter %q", key)
+}
+
+// globToLike turns a glob with * and ? into a LIKE pattern
+func globToLike(glob string) string {
+	escaped := escapeLike(glob)
+	escaped = strings.ReplaceAll(escaped, "*", "%")
+	return strings.ReplaceAll(escaped, "?", "_")
+}
+
+// recordOrder turns a sort parameter such as "zone_name,-updated_at" into an
+// ORDER BY clause, ending with the ID to keep pages stable
+func recordOrder(sortParam string) (string, error) {
+	var clauses []string
+	for _, field := range strings.Split(sortParam, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+			field = field[1:]
+		}
+		if !recordSortFields[field] {
+			return "", fmt.Errorf("cannot sort by %q", field)
+		}
+		clauses = append(clauses, field+" "+direction)
+	}
+	if len(clauses) == 0 {
+		clauses = append(clauses, "full_domain ASC")
+	}
+	return strings.Join(append(clauses, "id ASC"), ", "), nil
+}
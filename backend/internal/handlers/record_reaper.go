@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartReaper deletes expired ephemeral records from their provider and the
+// database in the background, every RECORD_REAPER_INTERVAL (default 1m).
+// Records that fail to delete are retried on the next run.
+func StartReaper() {
+	interval := time.Minute
+	if value, err := time.ParseDuration(os.Getenv("RECORD_REAPER_INTERVAL")); err == nil && value > 0 {
+		interval = value
+	}
+
+	log.Printf("RecordReaper: Checking for expired records every %s", interval)
+
+	go func() {
+		for {
+			reapExpiredRecords()
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// reapExpiredRecords deletes every managed record whose expiry has passed
+func reapExpiredRecords() {
+	var expired []models.DNSRecord
+	if err := database.DB.Where("managed = ? AND expires_at IS NOT NULL AND expires_at <= ?", true, time.Now().UTC()).
+		Order("expires_at ASC").Find(&expired).Error; err != nil {
+		log.Printf("RecordReaper: Failed to load expired records: %v", err)
+		return
+	}
+
+	actor := systemActor("reaper")
+	for i := range expired {
+		record := &expired[i]
+		if err := deleteRecordOp(actor, record, gin.H{
+			"reason":     "expired",
+			"expires_at": record.ExpiresAt,
+		}); err != nil {
+			log.Printf("RecordReaper: Failed to delete expired record %d (%s): %v", record.ID, record.FullDomain, err)
+			continue
+		}
+		log.Printf("RecordReaper: Deleted expired record %d (%s)", record.ID, record.FullDomain)
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// schedulerInterval is how often the scheduler looks for due changes
+const schedulerInterval = 15 * time.Second
+
+// ScheduleChangeRequest represents a record change to apply at a later time
+type ScheduleChangeRequest struct {
+	RecordID  uint                 `json:"record_id" binding:"required"`
+	Operation string               `json:"operation" binding:"required"` // update, enable or disable
+	ExecuteAt time.Time            `json:"execute_at" binding:"required"`
+	Record    *CreateRecordRequest `json:"record"` // target state, required for update
+}
+
+// CreateScheduledChange queues an update, enable or disable of a record for a
+// maintenance window. Changes that need four-eyes approval wait for it first.
+func CreateScheduledChange(c *gin.Context) {
+	var req ScheduleChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Operation != models.ChangeOpUpdate && req.Operation != models.ChangeOpEnable && req.Operation != models.ChangeOpDisable {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Operation must be update, enable or disable"})
+		return
+	}
+	if !req.ExecuteAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "execute_at must be in the future"})
+		return
+	}
+
+	permission := changeOpPermissions[req.Operation]
+	if !hasPermission(c, permission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied: scheduling " + req.Operation + " requires '" + permission + "'"})
+		return
+	}
+
+	var record models.DNSRecord
+	if err := database.DB.First(&record, req.RecordID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+		return
+	}
+
+	domain := record.FullDomain
+	isServer := record.IsServer
+	var payload string
+	if req.Operation == models.ChangeOpUpdate {
+		if req.Record == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "record is required for a scheduled update"})
+			return
+		}
+		if err := validateRecordType(req.Record.RecordType); err != nil {
+			respondRecordOpError(c, err)
+			return
+		}
+		if err := validateExpiryUpdate(&record, *req.Record); err != nil {
+			respondRecordOpError(c, err)
+			return
+		}
+		if changesDNSFields(&record, *req.Record) {
+			if findings, err := lintRequest(&record, *req.Record); err != nil {
+				respondLintError(c, findings, err)
+				return
+			}
+		}
+		domain = req.Record.FullDomain
+		isServer = isServer || req.Record.IsServer
+		payloadJSON, _ := json.Marshal(req.Record)
+		payload = string(payloadJSON)
+	} else if record.ProviderRecordID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Record has no provider reference"})
+		return
+	}
+
+	if !requireZoneEdit(c, record.FullDomain, domain) {
+		return
+	}
+
+	reason, err := approvalReason([]string{record.FullDomain, domain}, isServer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	executeAt := req.ExecuteAt.UTC()
+	snapshotJSON, _ := json.Marshal(record)
+	change := models.ChangeRequest{
+		Operation:   req.Operation,
+		RecordID:    record.ID,
+		Domain:      domain,
+		Payload:     payload,
+		Snapshot:    string(snapshotJSON),
+		Reason:      reason,
+		Status:      models.ChangeStatusScheduled,
+		RequestedBy: c.GetString(auth.ContextUsername),
+		ExecuteAt:   &executeAt,
+	}
+	if reason != "" {
+		change.Status = models.ChangeStatusPending
+	}
+
+	if err := database.DB.Create(&change).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save scheduled change"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeChange, change.ID, gin.H{
+		"operation":  change.Operation,
+		"domain":     change.Domain,
+		"record_id":  change.RecordID,
+		"reason":     change.Reason,
+		"execute_at": change.ExecuteAt,
+	}, nil, nil)
+
+	publishEvent(c, events.ChangeRequested, gin.H{"change_request": change})
+
+	if change.Status == models.ChangeStatusPending {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":        "Change requires approval (" + reason + ") before it is scheduled",
+			"change_request": change,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "Change scheduled for " + executeAt.Format(time.RFC3339),
+		"change_request": change,
+	})
+}
+
+// GetScheduledChanges lists changes with an execution time, soonest first,
+// optionally filtered by status
+func GetScheduledChanges(c *gin.Context) {
+	query := database.DB.Where("execute_at IS NOT NULL").Order("execute_at ASC").Limit(200)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var changes []models.ChangeRequest
+	if err := query.Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scheduled changes"})
+		return
+	}
+
+	scope := zoneScope(c)
+	visible := make([]models.ChangeRequest, 0, len(changes))
+	for _, change := range changes {
+		if scope.CanView(change.Domain) {
+			visible = append(visible, change)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scheduled_changes": visible})
+}
+
+// StartScheduler runs due scheduled changes in the background. Schedules live
+// in the database, so they survive restarts; changes that are overdue by more
+// than SCHEDULED_CHANGE_MAX_DELAY (default 1h) are failed instead of applied.
+func StartScheduler() {
+	maxDelay := time.Hour
+	if value, err := time.ParseDuration(os.Getenv("SCHEDULED_CHANGE_MAX_DELAY")); err == nil && value > 0 {
+		maxDelay = value
+	}
+
+	// A change claimed but not finished was interrupted mid-flight; its
+	// provider state is unknown, so it must not run a second time
+	now := time.Now()
+	result := database.DB.Model(&models.ChangeRequest{}).
+		Where("status = ? AND execute_at IS NOT NULL", models.ChangeStatusApproved).
+		Updates(map[string]interface{}{
+			"status":      models.ChangeStatusFailed,
+			"error":       "interrupted by restart, verify the record and reschedule",
+			"executed_at": now,
+		})
+	if result.Error != nil {
+		log.Printf("Scheduler: Failed to recover interrupted changes: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Scheduler: Marked %d interrupted scheduled change(s) as failed", result.RowsAffected)
+	}
+
+	log.Printf("Scheduler: Checking for due changes every %s (max delay %s)", schedulerInterval, maxDelay)
+
+	go func() {
+		for {
+			runDueChanges(maxDelay)
+			time.Sleep(schedulerInterval)
+		}
+	}()
+}
+
+// runDueChanges claims and applies every scheduled change whose time has come
+func runDueChanges(maxDelay time.Duration) {
+	var due []models.ChangeRequest
+	if err := database.DB.Where("status = ? AND execute_at <= ?", models.ChangeStatusScheduled, time.Now().UTC()).
+		Order("execute_at ASC").Find(&due).Error; err != nil {
+		log.Printf("Scheduler: Failed to load due changes: %v", err)
+		return
+	}
+
+	for i := range due {
+		change := &due[i]
+
+		// Protected zones or approval rules may have changed since scheduling
+		if change.ReviewedBy == "" {
+			reason, err := scheduledApprovalReason(change)
+			if err != nil {
+				log.Printf("Scheduler: Failed to check approval of change %d: %v", change.ID, err)
+				continue
+			}
+			if reason != "" {
+				holdForApproval(change, reason)
+				continue
+			}
+		}
+
+		// Claim the change so a cancel racing with execution cannot both win
+		result := database.DB.Model(&models.ChangeRequest{}).
+			Where("id = ? AND status = ?", change.ID, models.ChangeStatusScheduled).
+			Update("status", models.ChangeStatusApproved)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+		change.Status = models.ChangeStatusApproved
+
+		actor := systemActor("scheduler")
+		if late := time.Since(*change.ExecuteAt); late > maxDelay {
+			before := *change
+			now := time.Now()
+			change.Status = models.ChangeStatusFailed
+			change.Error = "missed execution window by " + late.Round(time.Second).String()
+			change.ExecutedAt = &now
+			if err := database.DB.Save(change).Error; err != nil {
+				log.Printf("Scheduler: Failed to save missed change %d: %v", change.ID, err)
+			}
+			logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeChange, change.ID, gin.H{
+				"operation": change.Operation,
+				"domain":    change.Domain,
+				"action":    "apply",
+				"status":    change.Status,
+			}, before, *change)
+			log.Printf("Scheduler: Change %d for %s %s", change.ID, change.Domain, change.Error)
+			continue
+		}
+
+		if _, err := applyChangeRequest(actor, change, gin.H{"scheduled_for": change.ExecuteAt}); err != nil {
+			log.Printf("Scheduler: Change %d (%s %s) failed: %v", change.ID, change.Operation, change.Domain, err)
+			continue
+		}
+		log.Printf("Scheduler: Applied change %d (%s %s)", change.ID, change.Operation, change.Domain)
+	}
+}
+
+// scheduledApprovalReason re-evaluates whether a scheduled change needs
+// approval under the current protected zones and approval rules
+func scheduledApprovalReason(change *models.ChangeRequest) (string, error) {
+	domains := []string{change.Domain}
+	isServer := false
+
+	var record models.DNSRecord
+	if err := database.DB.First(&record, change.RecordID).Error; err == nil {
+		domains = append(domains, record.FullDomain)
+		isServer = record.IsServer
+	}
+	var req CreateRecordRequest
+	if change.Payload != "" && json.Unmarshal([]byte(change.Payload), &req) == nil && req.IsServer {
+		isServer = true
+	}
+
+	return approvalReason(domains, isServer)
+}
+
+// holdForApproval moves an unreviewed scheduled change back to pending
+// approval; once approved it is scheduled again
+func holdForApproval(change *models.ChangeRequest, reason string) {
+	result := database.DB.Model(&models.ChangeRequest{}).
+		Where("id = ? AND status = ?", change.ID, models.ChangeStatusScheduled).
+		Updates(map[string]interface{}{"status": models.ChangeStatusPending, "reason": reason})
+	if result.Error != nil {
+		log.Printf("Scheduler: Failed to hold change %d for approval: %v", change.ID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	before := *change
+	change.Status = models.ChangeStatusPending
+	change.Reason = reason
+
+	actor := systemActor("scheduler")
+	logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeChange, change.ID, gin.H{
+		"operation": change.Operation,
+		"domain":    change.Domain,
+		"action":    "hold",
+		"reason":    reason,
+	}, before, *change)
+	publishActorEvent(actor, events.ChangeRequested, gin.H{"change_request": *change})
+
+	log.Printf("Scheduler: Change %d for %s now requires approval (%s), holding it", change.ID, change.Domain, reason)
+}
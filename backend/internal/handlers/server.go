@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/services"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServerRequest represents the request to create or update a server
+type ServerRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	Region  string   `json:"region"`
+	IPv4    []string `json:"ipv4"`
+	IPv6    []string `json:"ipv6"`
+	Hosting string   `json:"hosting"`
+	Tags    []string `json:"tags"`
+	Notes   string   `json:"notes"`
+}
+
+// serverSummary is a server with the number of records linked to it
+type serverSummary struct {
+	models.Server
+	RecordCount int `json:"record_count"`
+}
+
+// serverLinkMu keeps concurrent record writes from creating the same server twice
+var serverLinkMu sync.Mutex
+
+// BackfillServers creates servers for server records that predate the
+// servers table and links records to them. It runs at startup and is a
+// no-op once every record is linked.
+func BackfillServers() {
+	created, linked, err := linkServerRecords()
+	if err != nil {
+		log.Printf("Servers: Backfill failed: %v", err)
+		return
+	}
+	if created > 0 || linked > 0 {
+		log.Printf("Servers: Backfill created %d server(s) and linked %d record(s)", created, linked)
+	}
+}
+
+// linkServerRecords links managed records that belong to no server yet.
+// Server records join the server that has their address or become a new
+// server; A and AAAA records join the server with their address and CNAMEs
+// the server whose hostname they point at. Existing links are never changed,
+// so a server keeps its records when their addresses change.
+func linkServerRecords() (created, linked int, err error) {
+	serverLinkMu.Lock()
+	defer serverLinkMu.Unlock()
+
+	var servers []models.Server
+	if err := database.DB.Find(&servers).Error; err != nil {
+		return 0, 0, err
+	}
+	var records []models.DNSRecord
+	if err := database.DB.Where("managed = ?", true).Find(&records).Error; err != nil {
+		return 0, 0, err
+	}
+
+	var hostRecords, unlinkedHosts, unlinked []models.DNSRecord
+	for _, record := range records {
+		switch {
+		case record.ServerID != nil:
+			if record.IsServer {
+				hostRecords = append(hostRecords, record)
+			}
+		case record.IsServer:
+			unlinkedHosts = append(unlinkedHosts, record)
+		default:
+			unlinked = append(unlinked, record)
+		}
+	}
+
+	link := func(record *models.DNSRecord, serverID uint) error {
+		if err := linkRecord(record, serverID); err != nil {
+			return err
+		}
+		linked++
+		return nil
+	}
+
+	var newHosts []models.DNSRecord
+	for i := range unlinkedHosts {
+		host := &unlinkedHosts[i]
+		serverID, ok := services.ServerForRecord(*host, servers, hostRecords)
+		if !ok {
+			newHosts = append(newHosts, *host)
+			continue
+		}
+		if err := link(host, serverID); err != nil {
+			return created, linked, err
+		}
+		hostRecords = append(hostRecords, *host)
+	}
+
+	for _, plan := range services.PlanServers(newHosts) {
+		server, err := createPlannedServer(plan)
+		if err != nil {
+			return created, linked, err
+		}
+		servers = append(servers, server)
+		created++
+		linked += len(plan.Records)
+		hostRecords = append(hostRecords, plan.Records...)
+	}
+
+	for i := range unlinked {
+		if serverID, ok := services.ServerForRecord(unlinked[i], servers, hostRecords); ok {
+			if err := link(&unlinked[i], serverID); err != nil {
+				return created, linked, err
+			}
+		}
+	}
+
+	return created, linked, nil
+}
+
+// linkRecord links record to a server unless it already has one
+func linkRecord(record *models.DNSRecord, serverID uint) error {
+	if err := database.DB.Model(&models.DNSRecord{}).Where("id = ? AND server_id IS NULL", record.ID).
+		UpdateColumn("server_id", serverID).Error; err != nil {
+		return err
+	}
+	record.ServerID = &serverID
+	return nil
+}
+
+// createPlannedServer creates a planned server, links its records and audits it
+func createPlannedServer(plan services.PlannedServer) (models.Server, error) {
+	server := plan.Server
+	if err := database.DB.Create(&server).Error; err != nil {
+		return server, err
+	}
+
+	domains := make([]string, 0, len(plan.Records))
+	for i := range plan.Records {
+		if err := linkRecord(&plan.Records[i], server.ID); err != nil {
+			return server, err
+		}
+		domains = append(domains, plan.Records[i].FullDomain)
+	}
+
+	logActorAuditChange(systemActor("servers"), models.ActionCreate, models.ResourceTypeServer, server.ID, gin.H{
+		"name":    server.Name,
+		"records": domains,
+		"reason":  "server records without a server",
+	}, nil, server)
+	return server, nil
+}
+
+// assignServer links record to a server if it belongs to none, the way
+// linkServerRecords would, but only looks up the servers it could belong to
+func assignServer(record *models.DNSRecord) {
+	if record.ServerID != nil || !record.Managed {
+		return
+	}
+
+	serverLinkMu.Lock()
+	defer serverLinkMu.Unlock()
+
+	serverID, ok, err := findServerForRecord(*record)
+	if err != nil {
+		log.Printf("Servers: Failed to find server for record %d: %v", record.ID, err)
+		return
+	}
+	if ok {
+		if err := linkRecord(record, serverID); err != nil {
+			log.Printf("Servers: Failed to link record %d: %v", record.ID, err)
+		}
+		return
+	}
+
+	if record.IsServer {
+		plan := services.PlanServers([]models.DNSRecord{*record})[0]
+		server, err := createPlannedServer(plan)
+		if err != nil {
+			log.Printf("Servers: Failed to create server for record %d: %v", record.ID, err)
+			return
+		}
+		record.ServerID = &server.ID
+	}
+}
+
+// findServerForRecord looks up the server an unlinked record belongs to:
+// the server with its address for A and AAAA records, the server of the
+// server record it points at for CNAMEs
+func findServerForRecord(record models.DNSRecord) (uint, bool, error) {
+	switch record.RecordType {
+	case models.RecordTypeA, models.RecordTypeAAAA:
+		ip := net.ParseIP(record.TargetValue)
+		if ip == nil {
+			return 0, false, nil
+		}
+		// Addresses are comma-separated, so match whole list items
+		query := database.DB.Where("1 = 0")
+		for _, address := range []string{record.TargetValue, ip.String()} {
+			pattern := "%," + escapeLike(address) + ",%"
+			query = query.Or("(',' || ipv4 || ',') LIKE ? ESCAPE '\\' OR (',' || ipv6 || ',') LIKE ? ESCAPE '\\'", pattern, pattern)
+		}
+		var servers []models.Server
+		if err := database.DB.Where(query).Order("id ASC").Find(&servers).Error; err != nil {
+			return 0, false, err
+		}
+		serverID, ok := services.ServerForRecord(record, servers, nil)
+		return serverID, ok, nil
+	case models.RecordTypeCNAME:
+		target := strings.TrimSuffix(record.TargetValue, ".")
+		var hosts []models.DNSRecord
+		if err := database.DB.Where("managed = ? AND is_server = ? AND server_id IS NOT NULL AND full_domain IN ?",
+			true, true, []string{target, strings.ToLower(target)}).Order("id ASC").Find(&hosts).Error; err != nil {
+			return 0, false, err
+		}
+		serverID, ok := services.ServerForRecord(record, nil, hosts)
+		return serverID, ok, nil
+	}
+	return 0, false, nil
+}
+
+// validateServerID checks that an explicitly requested server exists
+func validateServerID(serverID *uint) error {
+	if serverID == nil {
+		return nil
+	}
+	var count int64
+	database.DB.Model(&models.Server{}).Where("id = ?", *serverID).Count(&count)
+	if count == 0 {
+		return newRecordOpError(http.StatusBadRequest, "Server not found")
+	}
+	return nil
+}
+
+// GetServers returns all servers with their record counts. Zone-restricted
+// callers only see servers with records in their zones.
+func GetServers(c *gin.Context) {
+	var servers []models.Server
+	if err := database.DB.Order("name ASC").Find(&servers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch servers"})
+		return
+	}
+
+	var records []models.DNSRecord
+	if err := database.DB.Select("id", "full_domain", "server_id").
+		Where("managed = ? AND server_id IS NOT NULL", true).Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+		return
+	}
+	records = filterVisibleRecords(c, records)
+
+	counts := make(map[uint]int)
+	for _, record := range records {
+		counts[*record.ServerID]++
+	}
+
+	unrestricted := zoneScope(c).Unrestricted
+	summaries := make([]serverSummary, 0, len(servers))
+	for _, server := range servers {
+		if !unrestricted && counts[server.ID] == 0 {
+			continue
+		}
+		summaries = append(summaries, serverSummary{Server: server, RecordCount: counts[server.ID]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"servers": summaries})
+}
+
+// GetServer returns a server with the records linked to it
+func GetServer(c *gin.Context) {
+	server, ok := loadServer(c)
+	if !ok {
+		return
+	}
+
+	var records []models.DNSRecord
+	if err := database.DB.Where("managed = ? AND server_id = ?", true, server.ID).
+		Order("full_domain ASC").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+		return
+	}
+	records = filterVisibleRecords(c, records)
+	if len(records) == 0 && !zoneScope(c).Unrestricted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"server": server, "records": records})
+}
+
+// CreateServer adds a server; records at its addresses are linked to it
+func CreateServer(c *gin.Context) {
+	if !requireUnrestrictedZones(c) {
+		return
+	}
+
+	var req ServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var server models.Server
+	if !applyServerRequest(c, &server, req) {
+		return
+	}
+
+	if err := database.DB.Create(&server).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save server"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeServer, server.ID, gin.H{
+		"name": server.Name,
+	}, nil, server)
+
+	_, linked, err := linkServerRecords()
+	if err != nil {
+		log.Printf("Servers: Failed to link records to server %d: %v", server.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Server created successfully",
+		"server":         server,
+		"linked_records": linked,
+	})
+}
+
+// UpdateServer changes a server's details. Records stay linked when its
+// addresses change; unlinked records at new addresses are linked.
+func UpdateServer(c *gin.Context) {
+	if !requireUnrestrictedZones(c) {
+		return
+	}
+
+	server, ok := loadServer(c)
+	if !ok {
+		return
+	}
+
+	var req ServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := *server
+	if !applyServerRequest(c, server, req) {
+		return
+	}
+
+	if err := database.DB.Save(server).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save server"})
+		return
+	}
+
+	logAuditChange(c, models.ActionUpdate, models.ResourceTypeServer, server.ID, gin.H{
+		"name": server.Name,
+	}, before, *server)
+
+	_, linked, err := linkServerRecords()
+	if err != nil {
+		log.Printf("Servers: Failed to link records to server %d: %v", server.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Server updated successfully",
+		"server":         server,
+		"linked_records": linked,
+	})
+}
+
+// DeleteServer removes a server that no managed record links to anymore
+func DeleteServer(c *gin.Context) {
+	if !requireUnrestrictedZones(c) {
+		return
+	}
+
+	server, ok := loadServer(c)
+	if !ok {
+		return
+	}
+
+	if err := removeServer(requestActor(c), server); err != nil {
+		respondRecordOpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Server deleted successfully"})
+}
+
+// removeServer deletes a server that has no managed records left
+func removeServer(actor auditActor, server *models.Server) error {
+	var count int64
+	database.DB.Model(&models.DNSRecord{}).Where("managed = ? AND server_id = ?", true, server.ID).Count(&count)
+	if count > 0 {
+		return newRecordOpError(http.StatusConflict, "Server still has "+strconv.FormatInt(count, 10)+" record(s), move or delete them first")
+	}
+
+	// Hidden records keep no reference to a server that no longer exists
+	if err := database.DB.Model(&models.DNSRecord{}).Where("server_id = ?", server.ID).
+		UpdateColumn("server_id", nil).Error; err != nil {
+		return newRecordOpError(http.StatusInternalServerError, "Failed to unlink records")
+	}
+
+	if err := database.DB.Delete(server).Error; err != nil {
+		return newRecordOpError(http.StatusInternalServerError, "Failed to delete server")
+	}
+
+	logActorAuditChange(actor, models.ActionDelete, models.ResourceTypeServer, server.ID, gin.H{
+		"name": server.Name,
+	}, *server, nil)
+
+	return nil
+}
+
+// loadServer loads the server named by the :id parameter
+func loadServer(c *gin.Context) (*models.Server, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return nil, false
+	}
+
+	var server models.Server
+	if err := database.DB.First(&server, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return nil, false
+	}
+	return &server, true
+}
+
+// applyServerRequest validates req and copies it onto server. An address may
+// only belong to one server, otherwise records could not be linked by it.
+func applyServerRequest(c *gin.Context, server *models.Server, req ServerRequest) bool {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return false
+	}
+
+	ipv4, ok := normalizeServerAddresses(c, req.IPv4, false)
+	if !ok {
+		return false
+	}
+	ipv6, ok := normalizeServerAddresses(c, req.IPv6, true)
+	if !ok {
+		return false
+	}
+
+	var others []models.Server
+	database.DB.Where("id <> ?", server.ID).Find(&others)
+	for _, other := range others {
+		for _, address := range services.ServerAddresses(other) {
+			for _, wanted := range append(ipv4, ipv6...) {
+				if net.ParseIP(address).Equal(net.ParseIP(wanted)) {
+					c.JSON(http.StatusConflict, gin.H{"error": "Address " + wanted + " already belongs to server " + other.Name})
+					return false
+				}
+			}
+		}
+	}
+
+	var tags []string
+	for _, tag := range req.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	server.Name = name
+	server.Region = strings.TrimSpace(req.Region)
+	server.IPv4 = strings.Join(ipv4, ",")
+	server.IPv6 = strings.Join(ipv6, ",")
+	server.Hosting = strings.TrimSpace(req.Hosting)
+	server.Tags = strings.Join(tags, ",")
+	server.Notes = req.Notes
+	return true
+}
+
+// normalizeServerAddresses parses and deduplicates a list of IPv4 or IPv6 addresses
+func normalizeServerAddresses(c *gin.Context, values []string, ipv6 bool) ([]string, bool) {
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, part := range auth.SplitList(value) {
+			ip := net.ParseIP(part)
+			if ip == nil || (ip.To4() == nil) != ipv6 {
+				family := "IPv4"
+				if ipv6 {
+					family = "IPv6"
+				}
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + family + " address: " + part})
+				return nil, false
+			}
+			if address := ip.String(); !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses, true
+}
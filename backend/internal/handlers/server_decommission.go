@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/services"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// What to do with a record of a decommissioned server
+const (
+	decommissionDelete  = "delete"
+	decommissionHide    = "hide"
+	decommissionRepoint = "repoint"
+)
+
+// DecommissionAction is the choice for one record of a decommissioned server
+type DecommissionAction struct {
+	RecordID       uint   `json:"record_id" binding:"required"`
+	Action         string `json:"action" binding:"required"` // delete, hide or repoint
+	TargetServerID uint   `json:"target_server_id"`          // server to repoint to
+}
+
+// DecommissionRequest represents the request to tear down a server
+type DecommissionRequest struct {
+	Actions      []DecommissionAction `json:"actions" binding:"required"` // one per record of the server
+	DeleteServer bool                 `json:"delete_server"`              // also delete the server afterwards
+	DryRun       bool                 `json:"dry_run"`                    // only return the planned batch
+}
+
+// GetServerDecommission lists the records a decommission of the server has to
+// handle: every managed record linked to it, as grouped under it on the
+// dashboard, with the health checks that would be removed
+func GetServerDecommission(c *gin.Context) {
+	if !requireUnrestrictedZones(c) {
+		return
+	}
+
+	server, ok := loadServer(c)
+	if !ok {
+		return
+	}
+
+	records, err := decommissionRecords(server.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+		return
+	}
+
+	checks, err := decommissionHealthChecks(records)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch health checks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server":        server,
+		"records":       records,
+		"health_checks": checks,
+	})
+}
+
+// DecommissionServer deletes, hides or repoints every record of a server as
+// a single batch, removes the health checks of those records and optionally
+// the server itself. Server records can be deleted here, unlike DeleteRecord.
+// A failed provider call rolls back the records already changed.
+func DecommissionServer(c *gin.Context) {
+	if !requireUnrestrictedZones(c) {
+		return
+	}
+
+	server, ok := loadServer(c)
+	if !ok {
+		return
+	}
+
+	var req DecommissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, err := decommissionRecords(server.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+		return
+	}
+
+	actions := make(map[uint]DecommissionAction, len(req.Actions))
+	for _, action := range req.Actions {
+		if _, dup := actions[action.RecordID]; dup {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Record %d has more than one action", action.RecordID)})
+			return
+		}
+		actions[action.RecordID] = action
+	}
+
+	// Repoints first so CNAMEs move away before their targets go, deletes last
+	var repoints, hides, deletes []batchOp
+	domains := make([]string, 0, len(records))
+	targets := make(map[uint]*decommissionTarget)
+	for i := range records {
+		record := &records[i]
+		action, ok := actions[record.ID]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No action given for " + record.FullDomain})
+			return
+		}
+		delete(actions, record.ID)
+		domains = append(domains, record.FullDomain)
+
+		switch action.Action {
+		case decommissionDelete:
+			deletes = append(deletes, batchOp{Op: models.ChangeOpDelete, Record: record, AllowServer: true})
+		case decommissionHide:
+			hides = append(hides, batchOp{Op: batchOpHide, Record: record})
+		case decommissionRepoint:
+			if action.TargetServerID == 0 || action.TargetServerID == server.ID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Repointing " + record.FullDomain + " needs another server as target_server_id"})
+				return
+			}
+			target, ok := targets[action.TargetServerID]
+			if !ok {
+				if target, err = loadDecommissionTarget(action.TargetServerID); err != nil {
+					respondRecordOpError(c, err)
+					return
+				}
+				targets[action.TargetServerID] = target
+			}
+			op, err := repointOp(record, target)
+			if err != nil {
+				respondRecordOpError(c, err)
+				return
+			}
+			repoints = append(repoints, op)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action " + action.Action + " for " + record.FullDomain})
+			return
+		}
+	}
+	// Actions left over name records the server does not have; report the lowest
+	if len(actions) > 0 {
+		unknown := make([]uint, 0, len(actions))
+		for recordID := range actions {
+			unknown = append(unknown, recordID)
+		}
+		sort.Slice(unknown, func(i, j int) bool { return unknown[i] < unknown[j] })
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Record %d is not a record of server %s", unknown[0], server.Name)})
+		return
+	}
+
+	ops := append(append(repoints, hides...), deletes...)
+
+	// The route only requires records:write; removing records needs the
+	// permission of DeleteRecord and HideRecord
+	if len(hides)+len(deletes) > 0 && !hasPermission(c, auth.PermRecordsDelete) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied: deleting or hiding records requires '" + auth.PermRecordsDelete + "'"})
+		return
+	}
+
+	if !requireZoneEdit(c, domains...) {
+		return
+	}
+
+	checks, err := decommissionHealthChecks(records)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch health checks"})
+		return
+	}
+
+	// Records being removed must not be the standby of another server's check
+	removed := make([]uint, 0, len(hides)+len(deletes))
+	for _, op := range append(append([]batchOp{}, hides...), deletes...) {
+		removed = append(removed, op.Record.ID)
+	}
+	if len(removed) > 0 {
+		var standby models.HealthCheck
+		if err := database.DB.Where("standby_record_id IN ? AND server_record_id NOT IN ?", removed, removed).First(&standby).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Health check %d uses a record of this server as standby, change it first", standby.ID)})
+			return
+		}
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"server":        server,
+			"batch":         planBatch(ops),
+			"health_checks": checks,
+			"delete_server": req.DeleteServer,
+		})
+		return
+	}
+
+	reason, err := batchApprovalReason(ops)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reason != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Decommission touches records that require approval (" + reason + "), change them individually"})
+		return
+	}
+
+	// Hold the checks so the checker cannot fail over while records change
+	sort.Slice(checks, func(i, j int) bool { return checks[i].ID < checks[j].ID })
+	for _, check := range checks {
+		healthCheckLocks.Lock(check.ID)
+		defer healthCheckLocks.Unlock(check.ID)
+	}
+	for i := range checks {
+		if err := database.DB.First(&checks[i], checks[i].ID).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Health checks changed, try again"})
+			return
+		}
+		if checks[i].FailedOver {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Health check %d is failed over, recover or delete it first", checks[i].ID)})
+			return
+		}
+	}
+
+	actor := requestActor(c)
+	result := runBatch(actor, ops, gin.H{
+		"server_id":    server.ID,
+		"decommission": server.Name,
+	})
+	if result.Status != batchStatusApplied {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Decommission failed, changed records were rolled back",
+			"batch": result,
+		})
+		return
+	}
+	actor.BatchID = result.BatchID
+
+	for i := range checks {
+		if err := database.DB.Delete(&checks[i]).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Records decommissioned but failed to delete health checks", "batch": result})
+			return
+		}
+		logActorAuditChange(actor, models.ActionDelete, models.ResourceTypeHealth, checks[i].ID, gin.H{
+			"server_record_id": checks[i].ServerRecordID,
+			"type":             checks[i].Type,
+			"decommission":     server.Name,
+		}, checks[i], nil)
+	}
+
+	if req.DeleteServer {
+		if err := removeServer(actor, server); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Records decommissioned but failed to delete server: " + err.Error(), "batch": result})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Server decommissioned successfully",
+		"batch":          result,
+		"health_checks":  len(checks),
+		"server_deleted": req.DeleteServer,
+	})
+}
+
+// decommissionRecords returns the managed records linked to a server
+func decommissionRecords(serverID uint) ([]models.DNSRecord, error) {
+	var records []models.DNSRecord
+	err := database.DB.Where("managed = ? AND server_id = ?", true, serverID).
+		Order("full_domain ASC").Find(&records).Error
+	return records, err
+}
+
+// decommissionHealthChecks returns the health checks probing any of records
+func decommissionHealthChecks(records []models.DNSRecord) ([]models.HealthCheck, error) {
+	checks := []models.HealthCheck{}
+	if len(records) == 0 {
+		return checks, nil
+	}
+	ids := make([]uint, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	err := database.DB.Where("server_record_id IN ?", ids).Find(&checks).Error
+	return checks, err
+}
+
+// decommissionTarget is a server records are repointed to
+type decommissionTarget struct {
+	Server   models.Server
+	IPv4     string // first IPv4 address, for A records
+	IPv6     string // first IPv6 address, for AAAA records
+	Hostname string // best server record, for CNAMEs
+}
+
+// loadDecommissionTarget loads a repoint target with its addresses and hostname
+func loadDecommissionTarget(serverID uint) (*decommissionTarget, error) {
+	target := &decommissionTarget{}
+	if err := database.DB.First(&target.Server, serverID).Error; err != nil {
+		return nil, newRecordOpError(http.StatusBadRequest, fmt.Sprintf("Server %d not found", serverID))
+	}
+
+	for _, address := range services.ServerAddresses(target.Server) {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil && target.IPv4 == "" {
+			target.IPv4 = ip.String()
+		} else if ip.To4() == nil && target.IPv6 == "" {
+			target.IPv6 = ip.String()
+		}
+	}
+
+	hostRecords, err := decommissionRecords(serverID)
+	if err != nil {
+		return nil, newRecordOpError(http.StatusInternalServerError, "Failed to fetch records")
+	}
+	if primary := services.PrimaryServerRecord(hostRecords); primary >= 0 {
+		target.Hostname = hostRecords[primary].FullDomain
+	}
+
+	return target, nil
+}
+
+// repointKind names what a repointed record type takes from the target server
+var repointKind = map[string]string{
+	models.RecordTypeA:     "IPv4 address",
+	models.RecordTypeAAAA:  "IPv6 address",
+	models.RecordTypeCNAME: "server record hostname",
+}
+
+// repointOp builds the update moving record to target: A and AAAA records to
+// its address, CNAMEs to its hostname. The record stops being a server record.
+func repointOp(record *models.DNSRecord, target *decommissionTarget) (batchOp, error) {
+	var value string
+	switch record.RecordType {
+	case models.RecordTypeA:
+		value = target.IPv4
+	case models.RecordTypeAAAA:
+		value = target.IPv6
+	case models.RecordTypeCNAME:
+		value = target.Hostname
+	default:
+		return batchOp{}, newRecordOpError(http.StatusBadRequest, record.RecordType+" record "+record.FullDomain+" cannot be repointed")
+	}
+	if value == "" {
+		return batchOp{}, newRecordOpError(http.StatusBadRequest, "Server "+target.Server.Name+" has no "+repointKind[record.RecordType]+" for "+record.FullDomain)
+	}
+
+	req := recordRequestWithTarget(record, value)
+	serverID := target.Server.ID
+	req.ServerID = &serverID
+	req.IsServer = false
+	req.ServerName = ""
+	req.ServerRegion = ""
+
+	return batchOp{Op: models.ChangeOpUpdate, Record: record, Request: req}, nil
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/services"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServerMigrationRequest represents the request to move a server to a new address
+type ServerMigrationRequest struct {
+	FromIP string `json:"from_ip"` // defaults to the server's only address
+	ToIP   string `json:"to_ip" binding:"required"`
+	DryRun bool   `json:"dry_run"` // only return the records that would change
+}
+
+// MigrateServer repoints every record at one of a server's addresses to a new
+// address as a single batch, then replaces the address on the server. A
+// failed provider update rolls back the records already changed.
+func MigrateServer(c *gin.Context) {
+	if !requireUnrestrictedZones(c) {
+		return
+	}
+
+	server, ok := loadServer(c)
+	if !ok {
+		return
+	}
+
+	var req ServerMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	addresses := services.ServerAddresses(*server)
+	fromIP := strings.TrimSpace(req.FromIP)
+	if fromIP == "" {
+		if len(addresses) != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from_ip is required for servers without exactly one address"})
+			return
+		}
+		fromIP = addresses[0]
+	}
+
+	from := net.ParseIP(fromIP)
+	to := net.ParseIP(strings.TrimSpace(req.ToIP))
+	if from == nil || to == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_ip and to_ip must be IP addresses"})
+		return
+	}
+	if (from.To4() == nil) != (to.To4() == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_ip and to_ip must be of the same address family"})
+		return
+	}
+	if from.Equal(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_ip must differ from from_ip"})
+		return
+	}
+
+	found := false
+	for _, address := range addresses {
+		found = found || net.ParseIP(address).Equal(from)
+	}
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{"error": from.String() + " is not an address of server " + server.Name})
+		return
+	}
+
+	var others []models.Server
+	database.DB.Where("id <> ?", server.ID).Find(&others)
+	for _, other := range others {
+		for _, address := range services.ServerAddresses(other) {
+			if net.ParseIP(address).Equal(to) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Address " + to.String() + " already belongs to server " + other.Name})
+				return
+			}
+		}
+	}
+
+	recordType := models.RecordTypeA
+	if from.To4() == nil {
+		recordType = models.RecordTypeAAAA
+	}
+
+	// The server's records plus any not linked to a server yet, across all providers
+	var records []models.DNSRecord
+	if err := database.DB.Where("managed = ? AND record_type = ? AND target_value = ?", true, recordType, from.String()).
+		Where("server_id = ? OR server_id IS NULL", server.ID).
+		Order("full_domain ASC").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+		return
+	}
+
+	ops := make([]batchOp, 0, len(records))
+	domains := make([]string, 0, len(records))
+	for i := range records {
+		ops = append(ops, batchOp{
+			Op:      models.ChangeOpUpdate,
+			Record:  &records[i],
+			Request: recordRequestWithTarget(&records[i], to.String()),
+		})
+		domains = append(domains, records[i].FullDomain)
+	}
+
+	if !requireZoneEdit(c, domains...) {
+		return
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"server":  server,
+			"from_ip": from.String(),
+			"to_ip":   to.String(),
+			"batch":   planBatch(ops),
+		})
+		return
+	}
+
+	reason, err := batchApprovalReason(ops)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reason != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Migration touches records that require approval (" + reason + "), change them individually"})
+		return
+	}
+
+	result := runBatch(requestActor(c), ops, gin.H{
+		"server_id": server.ID,
+		"migration": from.String() + " -> " + to.String(),
+	})
+	if result.Status != batchStatusApplied {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Migration failed, changed records were rolled back",
+			"batch": result,
+		})
+		return
+	}
+
+	before := *server
+	server.IPv4 = replaceServerAddress(server.IPv4, from, to)
+	server.IPv6 = replaceServerAddress(server.IPv6, from, to)
+	if err := database.DB.Save(server).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Records migrated but failed to save server address", "batch": result})
+		return
+	}
+
+	actor := requestActor(c)
+	actor.BatchID = result.BatchID
+	logActorAuditChange(actor, models.ActionUpdate, models.ResourceTypeServer, server.ID, gin.H{
+		"name":    server.Name,
+		"action":  "migrate",
+		"from_ip": from.String(),
+		"to_ip":   to.String(),
+		"records": len(records),
+	}, before, *server)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Server migrated successfully",
+		"server":  server,
+		"batch":   result,
+	})
+}
+
+// replaceServerAddress swaps from for to in a comma-separated address list
+func replaceServerAddress(list string, from, to net.IP) string {
+	addresses := strings.Split(list, ",")
+	for i, address := range addresses {
+		if net.ParseIP(strings.TrimSpace(address)).Equal(from) {
+			addresses[i] = to.String()
+		}
+	}
+	return strings.Join(addresses, ",")
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateUserRoleRequest represents the request to change a user's role
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// GetUsers returns all known users and their roles
+func GetUsers(c *gin.Context) {
+	var users []models.User
+	if err := database.DB.Order("username ASC").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// UpdateUserRole assigns a role to a user
+func UpdateUserRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !auth.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role, expected viewer, operator or admin"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Never leave the installation without an admin
+	if user.Role == models.RoleAdmin && req.Role != models.RoleAdmin {
+		var admins int64
+		if err := database.DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admins"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot demote the last admin"})
+			return
+		}
+	}
+
+	before := user
+	user.Role = req.Role
+	if err := database.DB.Save(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role"})
+		return
+	}
+
+	logAuditChange(c, models.ActionUpdate, models.ResourceTypeUser, user.ID, gin.H{
+		"username": user.Username,
+		"role":     user.Role,
+	}, before, user)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User role updated successfully",
+		"user":    user,
+	})
+}
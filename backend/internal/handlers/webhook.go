@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"dnsmesh/pkg/crypto"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookRequest represents the request to create or update a webhook subscription
+type WebhookRequest struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active"`
+}
+
+// GetWebhooks returns all webhook subscriptions
+func GetWebhooks(c *gin.Context) {
+	var hooks []models.Webhook
+	if err := database.DB.Order("id ASC").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks":       hooks,
+		"event_types":    events.Types,
+		"dropped_events": events.Dropped(),
+	})
+}
+
+// CreateWebhook creates a webhook subscription. The signing secret is generated
+// when not supplied and is only returned in this response.
+func CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook name is required"})
+		return
+	}
+
+	if err := validateWebhookRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = crypto.RandomToken(32); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+			return
+		}
+	}
+
+	encryptedSecret, err := crypto.Encrypt(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt webhook secret"})
+		return
+	}
+
+	hook := models.Webhook{
+		Name:       strings.TrimSpace(req.Name),
+		URL:        req.URL,
+		Secret:     encryptedSecret,
+		EventTypes: strings.Join(req.EventTypes, ","),
+		Active:     req.Active == nil || *req.Active,
+		CreatedBy:  c.GetString(auth.ContextUsername),
+	}
+
+	if err := database.DB.Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeWebhook, hook.ID, gin.H{
+		"name": hook.Name,
+		"url":  hook.URL,
+	}, nil, hook)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook created successfully",
+		"webhook": hook,
+		"secret":  secret,
+	})
+}
+
+// UpdateWebhook updates a webhook subscription
+func UpdateWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var hook models.Webhook
+	if err := database.DB.First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.URL == "" {
+		req.URL = hook.URL
+	}
+	if err := validateWebhookRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := hook
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		hook.Name = name
+	}
+	hook.URL = req.URL
+	if req.EventTypes != nil {
+		hook.EventTypes = strings.Join(req.EventTypes, ",")
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if req.Secret != "" {
+		encryptedSecret, err := crypto.Encrypt(req.Secret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt webhook secret"})
+			return
+		}
+		hook.Secret = encryptedSecret
+	}
+
+	if err := database.DB.Save(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	logAuditChange(c, models.ActionUpdate, models.ResourceTypeWebhook, hook.ID, gin.H{
+		"name":           hook.Name,
+		"secret_updated": req.Secret != "",
+	}, before, hook)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook updated successfully",
+		"webhook": hook,
+	})
+}
+
+// DeleteWebhook deletes a webhook subscription and its delivery log
+func DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var hook models.Webhook
+	if err := database.DB.First(&hook, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	if err := database.DB.Where("webhook_id = ?", hook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook deliveries"})
+		return
+	}
+
+	if err := database.DB.Delete(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	logAuditChange(c, models.ActionDelete, models.ResourceTypeWebhook, hook.ID, gin.H{
+		"name": hook.Name,
+	}, hook, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook, newest first
+func GetWebhookDeliveries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	query := database.DB.Where("webhook_id = ?", id).Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 200 {
+			limit = parsedLimit
+		}
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Limit(limit).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// validateWebhookRequest checks the target URL and subscribed event types
+func validateWebhookRequest(req *WebhookRequest) error {
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook URL, expected http(s)://host/path")
+	}
+
+	for _, eventType := range req.EventTypes {
+		if !events.IsValidType(eventType) {
+			return fmt.Errorf("unknown event type: %s", eventType)
+		}
+	}
+
+	return nil
+}
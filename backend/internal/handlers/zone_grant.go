@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ZoneGrantRequest represents the request to grant a group access to a zone
+type ZoneGrantRequest struct {
+	GroupName  string `json:"group_name" binding:"required"`
+	Zone       string `json:"zone" binding:"required"`
+	Permission string `json:"permission"`
+}
+
+// zoneScope returns the zone scope resolved by AuthRequired; requests without one see nothing
+func zoneScope(c *gin.Context) *auth.ZoneScope {
+	if value, exists := c.Get(auth.ContextZoneScope); exists {
+		if scope, ok := value.(*auth.ZoneScope); ok {
+			return scope
+		}
+	}
+	return &auth.ZoneScope{}
+}
+
+// requireZoneEdit aborts with 403 unless the caller may edit every given domain
+func requireZoneEdit(c *gin.Context, domains ...string) bool {
+	scope := zoneScope(c)
+	for _, domain := range domains {
+		if !scope.CanEdit(domain) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No edit permission for zone of " + domain})
+			return false
+		}
+	}
+	return true
+}
+
+// requireUnrestrictedZones aborts with 403 for zone-restricted callers, used for
+// operations that touch every zone of a provider at once
+func requireUnrestrictedZones(c *gin.Context) bool {
+	if !zoneScope(c).Unrestricted {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Zone-restricted users cannot run provider-wide operations"})
+		return false
+	}
+	return true
+}
+
+// filterVisibleRecords drops records outside the caller's zone scope
+func filterVisibleRecords(c *gin.Context, records []models.DNSRecord) []models.DNSRecord {
+	scope := zoneScope(c)
+	if scope.Unrestricted {
+		return records
+	}
+
+	visible := records[:0]
+	for _, record := range records {
+		if scope.CanView(record.FullDomain) {
+			visible = append(visible, record)
+		}
+	}
+	return visible
+}
+
+// scopeVisibleRecords restricts a records query to the caller's zones, the
+// SQL form of filterVisibleRecords for queries that paginate
+func scopeVisibleRecords(c *gin.Context, query *gorm.DB) *gorm.DB {
+	scope := zoneScope(c)
+	if scope.Unrestricted {
+		return query
+	}
+
+	condition := database.DB.Where("1 = 0")
+	for _, zone := range append(append([]string{}, scope.ViewZones...), scope.EditZones...) {
+		zone = auth.NormalizeDomain(zone)
+		if zone == "*" {
+			return query
+		}
+		condition = condition.Or("full_domain LIKE ? ESCAPE '\\' OR full_domain LIKE ? ESCAPE '\\'", escapeLike(zone), "%."+escapeLike(zone))
+	}
+	return query.Where(condition)
+}
+
+// eventVisible reports whether a change event may be streamed to the given scope
+func eventVisible(scope *auth.ZoneScope, event events.Event) bool {
+	if scope.Unrestricted {
+		return true
+	}
+	// Drift and dangling CNAME reports list domains across every zone
+	if event.Type == events.DriftDetected || event.Type == events.DanglingDetected {
+		return false
+	}
+	if data, ok := event.Data.(gin.H); ok {
+		if record, ok := data["record"].(models.DNSRecord); ok {
+			return scope.CanView(record.FullDomain)
+		}
+		if change, ok := data["change_request"].(models.ChangeRequest); ok {
+			return scope.CanView(change.Domain)
+		}
+		if domain, ok := data["domain"].(string); ok {
+			return scope.CanView(domain)
+		}
+	}
+	return true
+}
+
+// GetZoneGrants returns all group to zone grants
+func GetZoneGrants(c *gin.Context) {
+	var grants []models.ZoneGrant
+	if err := database.DB.Order("group_name ASC, zone ASC").Find(&grants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch zone grants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"grants": grants})
+}
+
+// CreateZoneGrant grants a reverse-proxy group view or edit access to a zone
+func CreateZoneGrant(c *gin.Context) {
+	var req ZoneGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Permission == "" {
+		req.Permission = models.ZonePermissionEdit
+	}
+	if req.Permission != models.ZonePermissionView && req.Permission != models.ZonePermissionEdit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid permission, expected view or edit"})
+		return
+	}
+
+	grant := models.ZoneGrant{
+		GroupName:  strings.TrimSpace(req.GroupName),
+		Zone:       auth.NormalizeDomain(req.Zone),
+		Permission: req.Permission,
+		CreatedBy:  c.GetString(auth.ContextUsername),
+	}
+	if grant.GroupName == "" || grant.Zone == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Group name and zone are required"})
+		return
+	}
+
+	if err := database.DB.Create(&grant).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save zone grant"})
+		return
+	}
+
+	logAuditChange(c, models.ActionCreate, models.ResourceTypeGrant, grant.ID, gin.H{
+		"group":      grant.GroupName,
+		"zone":       grant.Zone,
+		"permission": grant.Permission,
+	}, nil, grant)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Zone grant created successfully",
+		"grant":   grant,
+	})
+}
+
+// DeleteZoneGrant revokes a zone grant
+func DeleteZoneGrant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zone grant ID"})
+		return
+	}
+
+	var grant models.ZoneGrant
+	if err := database.DB.First(&grant, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Zone grant not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&grant).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete zone grant"})
+		return
+	}
+
+	logAuditChange(c, models.ActionDelete, models.ResourceTypeGrant, grant.ID, gin.H{
+		"group":      grant.GroupName,
+		"zone":       grant.Zone,
+		"permission": grant.Permission,
+	}, grant, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Zone grant deleted successfully"})
+}
@@ -0,0 +1,273 @@
+// Package lint checks DNS records against the other records of their zone
+// for mistakes providers or resolvers would reject or silently mishandle.
+package lint
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/models"
+)
+
+// Finding severities; errors block changes, warnings are only reported
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off"
+)
+
+// Rule IDs
+const (
+	RuleCNAMEApex      = "cname_apex"      // CNAME at the zone apex
+	RuleCNAMEConflict  = "cname_conflict"  // CNAME sharing its name with other records
+	RuleInvalidValue   = "invalid_value"   // malformed name, IP address or hostname
+	RuleTTLRange       = "ttl_range"       // TTL outside the provider's limits
+	RuleDuplicate      = "duplicate"       // same name, type and value twice
+	RuleWildcardShadow = "wildcard_shadow" // explicit names hiding a wildcard
+)
+
+// Rule describes a lint rule and its default severity
+type Rule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+}
+
+// Rules lists every rule with its default severity
+var Rules = []Rule{
+	{RuleCNAMEApex, "CNAME 不能位于 Zone 根域名", SeverityError},
+	{RuleCNAMEConflict, "CNAME 不能与同名的其他记录共存", SeverityError},
+	{RuleInvalidValue, "域名、IP 地址或 CNAME 目标格式无效", SeverityError},
+	{RuleTTLRange, "TTL 超出 DNS 提供商允许的范围", SeverityWarning}, // minimums depend on the plan
+	{RuleDuplicate, "存在名称、类型与值都相同的记录", SeverityError},
+	{RuleWildcardShadow, "同级的具体记录会让通配符记录对该名称失效", SeverityWarning},
+}
+
+// Config holds the severity of each rule; disabled rules are absent
+type Config struct {
+	Severities map[string]string
+}
+
+// TTLLimits are the TTLs a provider accepts; zero values are unbounded
+type TTLLimits struct {
+	Min  int
+	Max  int
+	Auto int // special value meaning automatic TTL, always allowed
+}
+
+// Finding is one rule violation of a record
+type Finding struct {
+	Rule       string `json:"rule"`
+	Severity   string `json:"severity"`
+	RecordID   uint   `json:"record_id,omitempty"`
+	Domain     string `json:"domain"`
+	RecordType string `json:"record_type"`
+	Message    string `json:"message"`
+}
+
+// DefaultConfig enables every rule at its default severity
+func DefaultConfig() Config {
+	cfg := Config{Severities: make(map[string]string, len(Rules))}
+	for _, rule := range Rules {
+		cfg.Severities[rule.ID] = rule.Severity
+	}
+	return cfg
+}
+
+// ConfigFromEnv reads LINT_RULES, a list of rule=severity pairs overriding
+// the defaults, e.g. "wildcard_shadow=off,ttl_range=error"
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	for _, entry := range auth.SplitList(os.Getenv("LINT_RULES")) {
+		id, severity, ok := strings.Cut(entry, "=")
+		id = strings.TrimSpace(id)
+		severity = strings.ToLower(strings.TrimSpace(severity))
+		if _, known := cfg.Severities[id]; !ok || !known {
+			log.Printf("Lint: Ignoring unknown rule setting %q", entry)
+			continue
+		}
+		switch severity {
+		case SeverityError, SeverityWarning:
+			cfg.Severities[id] = severity
+		case SeverityOff:
+			delete(cfg.Severities, id)
+		default:
+			log.Printf("Lint: Ignoring invalid severity %q for rule %s", severity, id)
+		}
+	}
+	return cfg
+}
+
+// Describe lists every rule with its configured severity
+func (cfg Config) Describe() []Rule {
+	rules := make([]Rule, 0, len(Rules))
+	for _, rule := range Rules {
+		rule.Severity = SeverityOff
+		if severity, ok := cfg.Severities[rule.ID]; ok {
+			rule.Severity = severity
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// HasErrors reports whether any finding is an error
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Check lints record against zone, the records of its zone. zone may contain
+// record itself, which is matched by ID and skipped.
+func (cfg Config) Check(record models.DNSRecord, zone []models.DNSRecord, limits TTLLimits) []Finding {
+	var findings []Finding
+	report := func(rule, format string, args ...interface{}) {
+		severity, enabled := cfg.Severities[rule]
+		if !enabled {
+			return
+		}
+		findings = append(findings, Finding{
+			Rule:       rule,
+			Severity:   severity,
+			RecordID:   record.ID,
+			Domain:     record.FullDomain,
+			RecordType: record.RecordType,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	name := normalizeName(record.FullDomain)
+	target := normalizeName(record.TargetValue)
+	var others []models.DNSRecord
+	for _, other := range zone {
+		if record.ID == 0 || other.ID != record.ID {
+			others = append(others, other)
+		}
+	}
+
+	if record.RecordType == models.RecordTypeCNAME && name == normalizeName(record.ZoneName) {
+		report(RuleCNAMEApex, "%s 是 Zone 根域名，不能设置 CNAME 记录", record.FullDomain)
+	}
+
+	var sameName []string
+	for _, other := range others {
+		if normalizeName(other.FullDomain) == name &&
+			(record.RecordType == models.RecordTypeCNAME || other.RecordType == models.RecordTypeCNAME) {
+			sameName = append(sameName, other.RecordType)
+		}
+	}
+	if len(sameName) > 0 {
+		report(RuleCNAMEConflict, "%s 已有 %s 记录，CNAME 不能与其他记录共存", record.FullDomain, strings.Join(uniqueSorted(sameName), "、"))
+	}
+
+	if !validHostname(name, true) {
+		report(RuleInvalidValue, "域名 %s 格式无效", record.FullDomain)
+	}
+	switch record.RecordType {
+	case models.RecordTypeA:
+		if ip := net.ParseIP(record.TargetValue); ip == nil || ip.To4() == nil || strings.Contains(record.TargetValue, ":") {
+			report(RuleInvalidValue, "%s 不是有效的 IPv4 地址", record.TargetValue)
+		}
+	case models.RecordTypeAAAA:
+		if ip := net.ParseIP(record.TargetValue); ip == nil || !strings.Contains(record.TargetValue, ":") {
+			report(RuleInvalidValue, "%s 不是有效的 IPv6 地址", record.TargetValue)
+		}
+	case models.RecordTypeCNAME:
+		if net.ParseIP(record.TargetValue) != nil || !validHostname(target, false) {
+			report(RuleInvalidValue, "CNAME 目标 %s 不是有效的主机名", record.TargetValue)
+		} else if target == name {
+			report(RuleInvalidValue, "CNAME 不能指向自身")
+		}
+	}
+
+	if ttl := record.TTL; ttl != 0 && !(limits.Auto != 0 && ttl == limits.Auto) {
+		if (limits.Min > 0 && ttl < limits.Min) || (limits.Max > 0 && ttl > limits.Max) {
+			report(RuleTTLRange, "TTL %d 超出 DNS 提供商允许的范围 %d–%d", ttl, limits.Min, limits.Max)
+		}
+	}
+
+	for _, other := range others {
+		if normalizeName(other.FullDomain) == name && other.RecordType == record.RecordType &&
+			normalizeName(other.TargetValue) == target {
+			report(RuleDuplicate, "已存在相同的 %s 记录 %s → %s", other.RecordType, other.FullDomain, other.TargetValue)
+			break
+		}
+	}
+
+	if parent, ok := strings.CutPrefix(name, "*."); ok {
+		var shadowed []string
+		for _, other := range others {
+			otherName := normalizeName(other.FullDomain)
+			if label, rest, found := strings.Cut(otherName, "."); found && rest == parent && label != "*" {
+				shadowed = append(shadowed, otherName)
+			}
+		}
+		if len(shadowed) > 0 {
+			shadowed = uniqueSorted(shadowed)
+			if len(shadowed) > 5 {
+				shadowed = append(shadowed[:5], "…")
+			}
+			report(RuleWildcardShadow, "%s 对已有具体记录的名称不生效：%s", record.FullDomain, strings.Join(shadowed, "、"))
+		}
+	} else if _, parent, found := strings.Cut(name, "."); found {
+		for _, other := range others {
+			if normalizeName(other.FullDomain) == "*."+parent {
+				report(RuleWildcardShadow, "%s 存在后，通配符记录 *.%s 不再对该名称的任何类型生效", record.FullDomain, parent)
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// normalizeName lowercases a domain name and strips its trailing dot
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// validHostname checks the syntax of a domain name. Underscores are allowed
+// for service labels, and a leading "*" label when wildcard is set.
+func validHostname(name string, wildcard bool) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if wildcard && i == 0 && label == "*" && len(labels) > 1 {
+			continue
+		}
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// uniqueSorted returns values sorted without duplicates
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -1,34 +1,64 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 
 	"dnsmesh/internal/auth"
+	"dnsmesh/internal/database"
+	"dnsmesh/internal/models"
+	"dnsmesh/internal/oidc"
 
 	"github.com/gin-gonic/gin"
 )
 
+// legacyTokenWarning logs the API_TOKEN deprecation or ignore notice once per process
+var legacyTokenWarning sync.Once
+
 // AuthRequired is a middleware that checks Remote-User header for authentication
-// Also supports API Key authentication via X-API-Key header
-func AuthRequired() gin.HandlerFunc {
+// Also supports API token authentication via X-API-Key or Authorization: Bearer.
+// Identity headers are only honoured from peers trusted by proxy.
+func AuthRequired(proxy *auth.ProxyConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Check for API Key first
-		apiKey := c.GetHeader("X-API-Key")
-		if apiKey != "" {
-			expectedKey := os.Getenv("API_TOKEN")
-			if expectedKey != "" && apiKey == expectedKey {
-				c.Set("username", "api-user")
+		// Anyone reaching the port directly could otherwise impersonate any user
+		dropUntrustedIdentity(c, proxy)
+
+		// Check for API tokens first
+		if token := apiTokenFromRequest(c); token != "" {
+			if authenticateAPIToken(c, token) {
 				c.Next()
 				return
 			}
-			// Invalid API key, continue to check other auth methods
+			if c.IsAborted() {
+				return
+			}
+			// Unknown key, continue to check other auth methods
+		}
+
+		// Check native OIDC session cookie
+		if oidc.Default != nil {
+			if cookie, err := c.Cookie(oidc.SessionCookie); err == nil && cookie != "" {
+				if session, err := oidc.Default.DecodeSession(cookie); err == nil {
+					if setUser(c, session.Username, auth.MethodOIDC, session.Groups) {
+						c.Set(auth.ContextClaims, session.Claims)
+						c.Next()
+					}
+					return
+				}
+			}
 		}
 
 		// Check bypass auth (development mode)
 		if username, ok := auth.BypassUser(); ok {
-			c.Set("username", username)
+			c.Set(auth.ContextUsername, username)
+			c.Set(auth.ContextAuthMethod, auth.MethodBypass)
+			c.Set(auth.ContextRole, auth.BypassRole())
+			c.Set(auth.ContextZoneScope, auth.UnrestrictedScope())
 			c.Next()
 			return
 		}
@@ -48,22 +78,184 @@ func AuthRequired() gin.HandlerFunc {
 		}
 
 		// Log all authentication-related headers for debugging
-		log.Printf("Auth check - Remote-User: '%s', remote-user: '%s', X-Remote-User: '%s', X-Forwarded-User: '%s'",
-			c.GetHeader("Remote-User"),
-			c.GetHeader("remote-user"),
-			c.GetHeader("X-Remote-User"),
-			c.GetHeader("X-Forwarded-User"))
+		if proxy.Debug {
+			log.Printf("Auth check - peer: '%s', Remote-User: '%s', remote-user: '%s', X-Remote-User: '%s', X-Forwarded-User: '%s'",
+				c.Request.RemoteAddr,
+				c.GetHeader("Remote-User"),
+				c.GetHeader("remote-user"),
+				c.GetHeader("X-Remote-User"),
+				c.GetHeader("X-Forwarded-User"))
+		}
 
 		if username == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authentication required: Remote-User header not found",
+			response := gin.H{"error": "Authentication required: Remote-User header not found"}
+			if oidc.Default != nil {
+				response["error"] = "Authentication required: please sign in"
+				response["login_url"] = "/api/auth/oidc/login"
+			}
+			c.JSON(http.StatusUnauthorized, response)
+			c.Abort()
+			return
+		}
+
+		if setUser(c, username, auth.MethodRemoteUser, auth.GroupsFromHeaders(c.Request.Header)) {
+			c.Next()
+		}
+	}
+}
+
+// dropUntrustedIdentity removes the identity and group headers from requests
+// not sent by a trusted proxy, so authentication falls through to the other methods
+func dropUntrustedIdentity(c *gin.Context, proxy *auth.ProxyConfig) {
+	header := c.Request.Header
+	present := false
+	for _, name := range auth.IdentityHeaders() {
+		if header.Get(name) != "" {
+			present = true
+		}
+	}
+	if !present || proxy.Trusts(c.Request) {
+		return
+	}
+
+	if proxy.Debug {
+		log.Printf("Auth check - ignoring identity headers from untrusted peer %s", c.Request.RemoteAddr)
+	}
+	for _, name := range auth.IdentityHeaders() {
+		header.Del(name)
+	}
+}
+
+// setUser loads (or provisions) the user, maps their groups to zone grants and
+// stores the identity in the context. It aborts the request and returns false on failure.
+func setUser(c *gin.Context, username, method string, groups []string) bool {
+	// Load the user's role, provisioning the user on first login
+	user, err := auth.EnsureUser(database.DB, username)
+	if err != nil {
+		log.Printf("Auth check - failed to load user '%s': %v", username, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		c.Abort()
+		return false
+	}
+
+	// Remember the groups so the user's API tokens follow the same grants
+	if err := auth.RecordGroups(database.DB, user, groups); err != nil {
+		log.Printf("Auth check - %v for '%s'", err, username)
+	}
+
+	// Map groups to zone grants
+	scope, err := auth.ResolveZoneScope(database.DB, user.Role, groups)
+	if err != nil {
+		log.Printf("Auth check - failed to resolve zone scope for '%s': %v", username, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load zone permissions"})
+		c.Abort()
+		return false
+	}
+
+	c.Set(auth.ContextUsername, username)
+	c.Set(auth.ContextAuthMethod, method)
+	c.Set(auth.ContextRole, user.Role)
+	c.Set(auth.ContextGroups, groups)
+	c.Set(auth.ContextZoneScope, scope)
+	return true
+}
+
+// RequirePermission is a middleware that rejects requests whose role lacks the permission.
+// It must run after AuthRequired.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString(auth.ContextRole)
+		if !auth.RoleHasPermission(role, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Permission denied: role '" + role + "' lacks '" + permission + "'",
+			})
+			c.Abort()
+			return
+		}
+
+		// API tokens are further limited to their own scopes
+		if scopes, ok := c.Get(auth.ContextScopes); ok && !auth.ScopesAllow(scopes.([]string), permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Permission denied: token '" + c.GetString(auth.ContextTokenName) + "' lacks '" + permission + "'",
 			})
 			c.Abort()
 			return
 		}
 
-		// Set username in context
-		c.Set("username", username)
 		c.Next()
 	}
 }
+
+// apiTokenFromRequest returns the token from X-API-Key, or from an
+// Authorization bearer header carrying one of our tokens. Other bearer values
+// are left alone because reverse proxies may forward their own access tokens.
+func apiTokenFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		bearer = strings.TrimSpace(bearer)
+		if strings.HasPrefix(bearer, auth.APITokenPrefix) {
+			return bearer
+		}
+	}
+	return ""
+}
+
+// authenticateAPIToken sets the request identity from a database token or the
+// deprecated API_TOKEN variable, which is only honoured when
+// LEGACY_API_TOKEN_ENABLED is set. It aborts with 401 when one of our tokens is
+// expired, revoked or unknown, and returns false without aborting for other keys.
+func authenticateAPIToken(c *gin.Context, token string) bool {
+	apiToken, owner, err := auth.AuthenticateAPIToken(database.DB, token)
+	if err == nil {
+		scope, err := auth.TokenZoneScope(database.DB, apiToken, owner)
+		if err != nil {
+			log.Printf("Auth check - failed to resolve zone scope for token %d: %v", apiToken.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load zone permissions"})
+			c.Abort()
+			return false
+		}
+
+		c.Set(auth.ContextUsername, owner.Username)
+		c.Set(auth.ContextAuthMethod, auth.MethodAPIToken)
+		c.Set(auth.ContextRole, owner.Role)
+		c.Set(auth.ContextZoneScope, scope)
+		c.Set(auth.ContextTokenID, apiToken.ID)
+		c.Set(auth.ContextTokenName, apiToken.Name)
+		c.Set(auth.ContextScopes, auth.SplitList(apiToken.Scopes))
+		return true
+	}
+	if !errors.Is(err, auth.ErrTokenInvalid) {
+		log.Printf("Auth check - API token rejected: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed: " + err.Error()})
+		c.Abort()
+		return false
+	}
+
+	// Deprecated: single all-powerful key from the environment, opt-in only
+	expectedKey := os.Getenv("API_TOKEN")
+	legacyEnabled := auth.IsTruthy(os.Getenv("LEGACY_API_TOKEN_ENABLED"))
+	if expectedKey != "" && !legacyEnabled {
+		legacyTokenWarning.Do(func() {
+			log.Println("Auth check - API_TOKEN is set but ignored, create scoped tokens via POST /api/tokens or set LEGACY_API_TOKEN_ENABLED=true")
+		})
+	}
+	if expectedKey != "" && legacyEnabled && subtle.ConstantTimeCompare([]byte(token), []byte(expectedKey)) == 1 {
+		legacyTokenWarning.Do(func() {
+			log.Println("Auth check - API_TOKEN is deprecated, create scoped tokens via POST /api/tokens and unset it")
+		})
+		c.Set(auth.ContextUsername, "api-user")
+		c.Set(auth.ContextAuthMethod, auth.MethodAPIToken)
+		c.Set(auth.ContextRole, models.RoleAdmin)
+		c.Set(auth.ContextZoneScope, auth.UnrestrictedScope())
+		c.Set(auth.ContextTokenName, "legacy-env")
+		return true
+	}
+
+	if strings.HasPrefix(token, auth.APITokenPrefix) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed: " + auth.ErrTokenInvalid.Error()})
+		c.Abort()
+	}
+	return false
+}
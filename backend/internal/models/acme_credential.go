@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// AcmeCredential lets an ACME client create and remove _acme-challenge TXT
+// records for a fixed set of domains, without access to provider credentials
+type AcmeCredential struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Username   string     `json:"username" gorm:"not null;uniqueIndex"` // HTTP basic auth user
+	SecretHash string     `json:"-" gorm:"not null"`                    // SHA-256 of the basic auth password
+	Domains    string     `json:"domains" gorm:"type:text"`             // comma-separated domains, each covering its subdomains
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
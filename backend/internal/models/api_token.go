@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"not null"`
+	Owner      string     `json:"owner" gorm:"not null;index"`   // username whose role caps the token
+	TokenHash  string     `json:"-" gorm:"not null;uniqueIndex"` // SHA-256 of the secret token
+	Prefix     string     `json:"prefix"`                        // leading characters shown to identify the token
+	Scopes     string     `json:"scopes" gorm:"type:text"`       // comma-separated permissions
+	Zones      string     `json:"zones" gorm:"type:text"`        // comma-separated zone suffixes, empty = owner's zones
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
@@ -10,20 +10,48 @@ type AuditLog struct {
 	ResourceType string    `json:"resource_type" gorm:"index"`   // record, provider
 	ResourceID   uint      `json:"resource_id"`
 	Details      string    `json:"details" gorm:"type:text"` // JSON details
+	Changes      string    `json:"changes" gorm:"type:text"` // JSON field diff: {"field": {"before": x, "after": y}}
+	Actor        string    `json:"actor" gorm:"index"`       // authenticated username
+	AuthMethod   string    `json:"auth_method"`              // remote_user, api_token, bypass
 	IPAddress    string    `json:"ip_address"`
+	TokenID      uint      `json:"token_id,omitempty" gorm:"index"` // API token that acted, if any
+	TokenName    string    `json:"token_name,omitempty"`
+	BatchID      string    `json:"batch_id,omitempty" gorm:"index"` // groups the entries of one batch operation
+	PrevHash     string    `json:"prev_hash"`                       // hash of the preceding entry
+	Hash         string    `json:"hash" gorm:"index"`               // SHA-256 over content + prev_hash
 	CreatedAt    time.Time `json:"created_at" gorm:"index"`
 }
 
+// AuditChainState records where the audit hash chain starts. Audit rows with
+// an ID below HashedFromID were written before hashing was introduced.
+type AuditChainState struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	HashedFromID uint      `json:"hashed_from_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 // Action constants
 const (
 	ActionCreate = "create"
 	ActionUpdate = "update"
 	ActionDelete = "delete"
 	ActionSync   = "sync"
+	ActionPrune  = "prune"
 )
 
 // ResourceType constants
 const (
 	ResourceTypeRecord   = "record"
 	ResourceTypeProvider = "provider"
+	ResourceTypeAuditLog = "audit_log"
+	ResourceTypeWebhook  = "webhook"
+	ResourceTypeUser     = "user"
+	ResourceTypeGrant    = "zone_grant"
+	ResourceTypeAPIToken = "api_token"
+	ResourceTypeChange   = "change_request"
+	ResourceTypeZone     = "protected_zone"
+	ResourceTypeACME     = "acme_credential"
+	ResourceTypeDynDNS   = "dyndns_credential"
+	ResourceTypeHealth   = "health_check"
+	ResourceTypeServer   = "server"
 )
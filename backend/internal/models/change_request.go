@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// ChangeRequest is a record change held back until a second user approves it
+type ChangeRequest struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Operation      string     `json:"operation" gorm:"not null;index"` // create, update, delete, enable, disable
+	RecordID       uint       `json:"record_id" gorm:"index"`          // 0 for create
+	Domain         string     `json:"domain" gorm:"index"`
+	Payload        string     `json:"payload" gorm:"type:text"`  // JSON record request for create and update
+	Snapshot       string     `json:"snapshot" gorm:"type:text"` // JSON record state when the change was requested
+	Reason         string     `json:"reason"`                    // why approval is required
+	Status         string     `json:"status" gorm:"not null;index"`
+	RequestedBy    string     `json:"requested_by" gorm:"index"`
+	ReviewedBy     string     `json:"reviewed_by"`
+	ReviewComment  string     `json:"review_comment" gorm:"type:text"`
+	ReviewedAt     *time.Time `json:"reviewed_at"`
+	Error          string     `json:"error" gorm:"type:text"`  // provider error when execution failed
+	ExecuteAt      *time.Time `json:"execute_at" gorm:"index"` // scheduled execution time, nil = on approval
+	ExecutedAt     *time.Time `json:"executed_at"`
+	ResultRecordID uint       `json:"result_record_id"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ChangeRequest operation constants
+const (
+	ChangeOpCreate  = "create"
+	ChangeOpUpdate  = "update"
+	ChangeOpDelete  = "delete"
+	ChangeOpEnable  = "enable"
+	ChangeOpDisable = "disable"
+)
+
+// ChangeRequest status constants
+const (
+	ChangeStatusPending   = "pending"
+	ChangeStatusApproved  = "approved"  // claimed by an approver or the scheduler, executing
+	ChangeStatusScheduled = "scheduled" // approved or not requiring approval, waiting for execute_at
+	ChangeStatusApplied   = "applied"
+	ChangeStatusFailed    = "failed"
+	ChangeStatusRejected  = "rejected"
+	ChangeStatusCancelled = "cancelled"
+)
+
+// ProtectedZone marks a zone whose record changes require four-eyes approval
+type ProtectedZone struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Zone      string    `json:"zone" gorm:"not null;uniqueIndex"` // domain suffix, e.g. example.com
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
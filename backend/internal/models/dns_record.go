@@ -5,23 +5,30 @@ import (
 )
 
 type DNSRecord struct {
-	ID               uint      `json:"id" gorm:"primaryKey"`
-	ProviderID       uint      `json:"provider_id" gorm:"not null;index"`
-	ZoneID           string    `json:"zone_id" gorm:"index"`         // Provider's zone ID
-	ZoneName         string    `json:"zone_name" gorm:"index"`       // e.g., example.com
-	FullDomain       string    `json:"full_domain" gorm:"index"`     // e.g., app1.example.com
-	RecordType       string    `json:"record_type" gorm:"not null"`  // A, CNAME
-	TargetValue      string    `json:"target_value" gorm:"not null"` // IP or domain
-	TTL              int       `json:"ttl" gorm:"default:600"`
-	IsServer         bool      `json:"is_server" gorm:"default:false;index"`
-	ServerName       string    `json:"server_name"`   // e.g., hk-01
-	ServerRegion     string    `json:"server_region"` // e.g., 香港
-	Notes            string    `json:"notes" gorm:"type:text"`
-	Active           bool      `json:"active" gorm:"default:true;index"`
-	ProviderRecordID string    `json:"provider_record_id"`          // Provider's record ID
-	Managed          bool      `json:"managed" gorm:"default:true"` // Whether managed by this system
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	ProviderID       uint       `json:"provider_id" gorm:"not null;index"`
+	ZoneID           string     `json:"zone_id" gorm:"index"`         // Provider's zone ID
+	ZoneName         string     `json:"zone_name" gorm:"index"`       // e.g., example.com
+	FullDomain       string     `json:"full_domain" gorm:"index"`     // e.g., app1.example.com
+	RecordType       string     `json:"record_type" gorm:"not null"`  // A, CNAME
+	TargetValue      string     `json:"target_value" gorm:"not null"` // IP or domain
+	TTL              int        `json:"ttl" gorm:"default:600"`
+	IsServer         bool       `json:"is_server" gorm:"default:false;index"`
+	ServerName       string     `json:"server_name"`            // e.g., hk-01
+	ServerRegion     string     `json:"server_region"`          // e.g., 香港
+	ServerID         *uint      `json:"server_id" gorm:"index"` // Server this record belongs to
+	Notes            string     `json:"notes" gorm:"type:text"`
+	Active           bool       `json:"active" gorm:"default:true;index"`
+	ProviderRecordID string     `json:"provider_record_id"`                // Provider's record ID
+	Managed          bool       `json:"managed" gorm:"default:true"`       // Whether managed by this system
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" gorm:"index"` // Ephemeral records are deleted by the reaper after this
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Outcome of the last DNS propagation check: pending, propagated or timeout
+	PropagationStatus    string     `json:"propagation_status,omitempty" gorm:"index"`
+	PropagationResult    string     `json:"propagation_result,omitempty" gorm:"type:text"` // JSON answers per nameserver/resolver
+	PropagationCheckedAt *time.Time `json:"propagation_checked_at,omitempty"`
 
 	// Relations
 	Provider Provider `json:"provider,omitempty" gorm:"foreignKey:ProviderID"`
@@ -30,5 +37,7 @@ type DNSRecord struct {
 // RecordType constants
 const (
 	RecordTypeA     = "A"
+	RecordTypeAAAA  = "AAAA"
 	RecordTypeCNAME = "CNAME"
+	RecordTypeTXT   = "TXT" // only created for ACME challenges, not synced
 )
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// DynDNSCredential lets a dyndns2 client (router, NAS) update the address of
+// specific hostnames, without access to provider credentials
+type DynDNSCredential struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Username   string     `json:"username" gorm:"not null;uniqueIndex"` // HTTP basic auth user
+	SecretHash string     `json:"-" gorm:"not null"`                    // SHA-256 of the basic auth password
+	Hostnames  string     `json:"hostnames" gorm:"type:text"`           // comma-separated hostnames, matched exactly
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastIP     string     `json:"last_ip"` // address from the most recent update
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
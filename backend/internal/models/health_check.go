@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// HealthCheck probes a server record and fails its A records over to a
+// standby address after repeated failures
+type HealthCheck struct {
+	ID                uint   `json:"id" gorm:"primaryKey"`
+	ServerRecordID    uint   `json:"server_record_id" gorm:"not null;uniqueIndex"` // the IsServer A record being probed
+	Type              string `json:"type" gorm:"not null"`                         // tcp, http, https
+	Port              int    `json:"port"`
+	Path              string `json:"path"`            // http(s) only
+	ExpectedStatus    int    `json:"expected_status"` // http(s) only, 0 = any 2xx or 3xx
+	IntervalSeconds   int    `json:"interval_seconds"`
+	TimeoutSeconds    int    `json:"timeout_seconds"`
+	FailureThreshold  int    `json:"failure_threshold"`  // consecutive failures before failing over
+	RecoveryThreshold int    `json:"recovery_threshold"` // consecutive successes before switching back
+	StandbyIP         string `json:"standby_ip"`         // either a fixed standby address...
+	StandbyRecordID   uint   `json:"standby_record_id"`  // ...or the current address of another server record (a DNSRecord ID)
+	Enabled           bool   `json:"enabled" gorm:"default:true"`
+
+	// Probe state
+	Status               string     `json:"status"` // unknown, healthy, unhealthy
+	ConsecutiveFailures  int        `json:"consecutive_failures"`
+	ConsecutiveSuccesses int        `json:"consecutive_successes"`
+	LastCheckedAt        *time.Time `json:"last_checked_at"`
+	LastError            string     `json:"last_error"`
+
+	// Failover state; the swapped records are switched back on recovery
+	FailedOver        bool       `json:"failed_over"`
+	PrimaryIP         string     `json:"primary_ip"`                           // address probed while failed over
+	ActiveStandbyIP   string     `json:"active_standby_ip"`                    // address the records were moved to
+	FailoverRecordIDs string     `json:"failover_record_ids" gorm:"type:text"` // comma-separated record IDs
+	FailedOverAt      *time.Time `json:"failed_over_at"`
+
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HealthCheck types
+const (
+	HealthCheckTCP   = "tcp"
+	HealthCheckHTTP  = "http"
+	HealthCheckHTTPS = "https"
+)
+
+// HealthCheck statuses
+const (
+	HealthStatusUnknown   = "unknown"
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+)
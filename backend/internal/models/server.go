@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// Server is a machine that DNS records point at. It keeps its identity when
+// its addresses change or its records are deleted; records link to it
+// through DNSRecord.ServerID.
+type Server struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null;index"` // e.g., hk-01
+	Region    string    `json:"region"`                     // e.g., 香港
+	IPv4      string    `json:"ipv4"`                       // comma-separated addresses
+	IPv6      string    `json:"ipv6"`                       // comma-separated addresses
+	Hosting   string    `json:"hosting"`                    // hosting provider, e.g. Hetzner
+	Tags      string    `json:"tags"`                       // comma-separated
+	Notes     string    `json:"notes" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+type User struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Username   string     `json:"username" gorm:"not null;uniqueIndex"`
+	Role       string     `json:"role" gorm:"not null;default:viewer"` // viewer, operator, admin
+	Groups     string     `json:"groups"`                              // comma-separated groups of the last interactive login
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Role constants, from least to most privileged
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+type Webhook struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name" gorm:"not null"`
+	URL        string    `json:"url" gorm:"not null"`
+	Secret     string    `json:"-" gorm:"type:text"`           // encrypted HMAC signing secret
+	EventTypes string    `json:"event_types" gorm:"type:text"` // comma-separated, empty = all events
+	Active     bool      `json:"active" gorm:"default:true"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	WebhookID      uint       `json:"webhook_id" gorm:"not null;index"`
+	EventType      string     `json:"event_type" gorm:"index"`
+	Payload        string     `json:"payload" gorm:"type:text"` // JSON event body as sent
+	Status         string     `json:"status" gorm:"index"`      // pending, succeeded, failed
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookDelivery status constants
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+type ZoneGrant struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	GroupName  string    `json:"group_name" gorm:"not null;index"` // reverse-proxy group, e.g. team-payments
+	Zone       string    `json:"zone" gorm:"not null"`             // domain suffix, e.g. pay.example.com
+	Permission string    `json:"permission" gorm:"not null"`       // view, edit
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ZoneGrant permission constants
+const (
+	ZonePermissionView = "view"
+	ZonePermissionEdit = "edit"
+)
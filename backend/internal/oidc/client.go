@@ -0,0 +1,374 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	dnscrypto "dnsmesh/pkg/crypto"
+)
+
+// jwksRefreshInterval limits refetching the key set when an unknown key ID shows up
+const jwksRefreshInterval = time.Minute
+
+// sessionClaims are copied from the ID token into the session and returned by /api/auth/user
+var sessionClaims = []string{"iss", "sub", "email", "email_verified", "name", "preferred_username"}
+
+// discoveryDocument is the subset of /.well-known/openid-configuration we use
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// Client runs the authorization-code flow with PKCE against one issuer
+type Client struct {
+	cfg        *Config
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]jsonWebKey
+	keysFetchedAt time.Time
+}
+
+// NewClient returns a client; provider metadata is discovered on first use
+func NewClient(cfg *Config) *Client {
+	return &Client{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// BeginLogin returns the authorization URL to redirect the browser to and the
+// signed flow cookie binding state, nonce and PKCE verifier to this browser
+func (c *Client) BeginLogin(ctx context.Context, redirect string) (string, string, error) {
+	doc, err := c.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	flow := flowState{
+		Redirect:  c.safeRedirect(redirect),
+		ExpiresAt: time.Now().Add(flowTTL).Unix(),
+	}
+	for _, value := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		if *value, err = dnscrypto.RandomToken(32); err != nil {
+			return "", "", err
+		}
+	}
+
+	cookie, err := sign(c.cfg.SessionSecret, purposeFlow, flow)
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(flow.Verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"scope":                 {strings.Join(c.cfg.Scopes, " ")},
+		"state":                 {flow.State},
+		"nonce":                 {flow.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	authURL := doc.AuthorizationEndpoint
+	if strings.Contains(authURL, "?") {
+		authURL += "&" + params.Encode()
+	} else {
+		authURL += "?" + params.Encode()
+	}
+
+	return authURL, cookie, nil
+}
+
+// FinishLogin validates the callback against the flow cookie, redeems the code
+// and verifies the ID token. It returns the new session and where to send the user.
+func (c *Client) FinishLogin(ctx context.Context, flowCookie, state, code string) (*Session, string, error) {
+	var flow flowState
+	if err := verify(c.cfg.SessionSecret, purposeFlow, flowCookie, &flow); err != nil {
+		return nil, "", fmt.Errorf("login flow: %w", err)
+	}
+	if time.Now().Unix() > flow.ExpiresAt {
+		return nil, "", errors.New("login flow expired, please sign in again")
+	}
+	if state == "" || state != flow.State {
+		return nil, "", errors.New("state mismatch")
+	}
+	if code == "" {
+		return nil, "", errors.New("missing authorization code")
+	}
+
+	rawIDToken, err := c.exchangeCode(ctx, code, flow.Verifier)
+	if err != nil {
+		return nil, "", err
+	}
+
+	claims, err := c.verifyIDToken(ctx, rawIDToken, flow.Nonce)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	session := &Session{
+		Subject:   stringClaim(claims, "sub"),
+		Username:  c.username(claims),
+		Groups:    listClaim(claims[c.cfg.GroupsClaim]),
+		Claims:    make(map[string]interface{}),
+		ExpiresAt: time.Now().Add(c.cfg.SessionTTL).Unix(),
+	}
+	for _, name := range append(sessionClaims, c.cfg.UsernameClaim, c.cfg.GroupsClaim) {
+		if value, ok := claims[name]; ok {
+			session.Claims[name] = value
+		}
+	}
+	if session.Subject == "" {
+		return nil, "", errors.New("ID token carries no subject")
+	}
+	if session.Username == "" {
+		return nil, "", errors.New("ID token carries no usable username claim")
+	}
+
+	return session, flow.Redirect, nil
+}
+
+// LogoutURL returns the provider's end-session endpoint, if it advertises one
+func (c *Client) LogoutURL(ctx context.Context) string {
+	doc, err := c.discover(ctx)
+	if err != nil || doc.EndSessionEndpoint == "" {
+		return ""
+	}
+	return doc.EndSessionEndpoint + "?" + url.Values{"client_id": {c.cfg.ClientID}}.Encode()
+}
+
+// safeRedirect only allows same-origin relative paths to avoid open redirects
+func (c *Client) safeRedirect(redirect string) string {
+	if strings.HasPrefix(redirect, "/") && !strings.HasPrefix(redirect, "//") && !strings.HasPrefix(redirect, "/\\") {
+		return redirect
+	}
+	return c.cfg.PostLoginURL
+}
+
+// username picks the configured claim, falling back to email and then subject
+func (c *Client) username(claims map[string]interface{}) string {
+	for _, name := range []string{c.cfg.UsernameClaim, "email", "sub"} {
+		if value := stringClaim(claims, name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// discover fetches and caches the provider metadata
+func (c *Client) discover(ctx context.Context) (*discoveryDocument, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	var doc discoveryDocument
+	if err := c.getJSON(ctx, c.cfg.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != c.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC discovery returned issuer %q, expected %q", doc.Issuer, c.cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document is missing required endpoints")
+	}
+
+	c.discovery = &doc
+	return c.discovery, nil
+}
+
+// exchangeCode redeems an authorization code and returns the raw ID token
+func (c *Client) exchangeCode(ctx context.Context, code, verifier string) (string, error) {
+	doc, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"client_id":     {c.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+
+	return tokens.IDToken, nil
+}
+
+// verifyIDToken checks the signature and registered claims of an ID token and
+// returns all of its claims
+func (c *Client) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (map[string]interface{}, error) {
+	header, payload, signingInput, sig, err := parseJWT(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := c.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, signingInput, sig); err != nil {
+		return nil, err
+	}
+
+	var registered idTokenClaims
+	if err := json.Unmarshal(payload, &registered); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+	if err := registered.validate(c.cfg.Issuer, c.cfg.ClientID, nonce, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+	return claims, nil
+}
+
+// signingKey returns the provider key for kid, refetching the JWKS when the
+// key is unknown so that provider key rotation is picked up
+func (c *Client) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	doc, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.lookupKey(kid); ok {
+		return key.publicKey()
+	}
+	if time.Since(c.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := c.getJSON(ctx, doc.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	c.keys = make(map[string]jsonWebKey)
+	for _, key := range jwks.Keys {
+		if key.Use == "" || key.Use == "sig" {
+			c.keys[key.Kid] = key
+		}
+	}
+	c.keysFetchedAt = time.Now()
+
+	if key, ok := c.lookupKey(kid); ok {
+		return key.publicKey()
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a cached key; tokens without kid match a single-key set
+func (c *Client) lookupKey(kid string) (jsonWebKey, bool) {
+	if key, ok := c.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	return jsonWebKey{}, false
+}
+
+// getJSON fetches url and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// stringClaim returns a string claim or ""
+func stringClaim(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// listClaim accepts a JSON array or a comma/space separated string
+func listClaim(value interface{}) []string {
+	var items []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				items = append(items, s)
+			}
+		}
+	case string:
+		items = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return items
+}
@@ -0,0 +1,92 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Default is the configured OIDC client, or nil when native login is disabled
+var Default *Client
+
+// Config holds the OIDC relying-party settings
+type Config struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string // empty for public clients, which rely on PKCE alone
+	RedirectURL   string // must point at /api/auth/oidc/callback
+	Scopes        []string
+	UsernameClaim string
+	GroupsClaim   string
+	SessionSecret string
+	SessionTTL    time.Duration
+	PostLoginURL  string
+}
+
+// ConfigFromEnv reads the OIDC settings; it returns nil when OIDC_ISSUER is unset
+func ConfigFromEnv() (*Config, error) {
+	issuer := strings.TrimSpace(os.Getenv("OIDC_ISSUER"))
+	if issuer == "" {
+		return nil, nil
+	}
+
+	cfg := &Config{
+		Issuer:        strings.TrimSuffix(issuer, "/"),
+		ClientID:      os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret:  os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:   os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:        strings.Fields(os.Getenv("OIDC_SCOPES")),
+		UsernameClaim: os.Getenv("OIDC_USERNAME_CLAIM"),
+		GroupsClaim:   os.Getenv("OIDC_GROUPS_CLAIM"),
+		SessionSecret: os.Getenv("SESSION_SECRET"),
+		SessionTTL:    12 * time.Hour,
+		PostLoginURL:  os.Getenv("OIDC_POST_LOGIN_URL"),
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email", "groups"}
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "preferred_username"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if cfg.PostLoginURL == "" {
+		cfg.PostLoginURL = "/"
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("SESSION_TTL")); err == nil && ttl > 0 {
+		cfg.SessionTTL = ttl
+	}
+
+	if cfg.ClientID == "" {
+		return nil, errors.New("OIDC_CLIENT_ID is required when OIDC_ISSUER is set")
+	}
+	if _, err := url.ParseRequestURI(cfg.RedirectURL); err != nil {
+		return nil, fmt.Errorf("OIDC_REDIRECT_URL must be an absolute URL: %w", err)
+	}
+	if len(cfg.SessionSecret) < 32 {
+		return nil, errors.New("SESSION_SECRET must be at least 32 characters when OIDC is enabled")
+	}
+
+	return cfg, nil
+}
+
+// Initialize configures Default from the environment
+func Initialize() error {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return nil
+	}
+
+	Default = NewClient(cfg)
+	log.Printf("OIDC: Native login enabled for issuer %s (client %s)", cfg.Issuer, cfg.ClientID)
+	return nil
+}
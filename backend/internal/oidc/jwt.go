@@ -0,0 +1,176 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew tolerates small clock differences with the identity provider
+const clockSkew = 2 * time.Minute
+
+// jsonWebKey is a single key from the provider's JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey converts a JWK into an RSA or ECDSA P-256 public key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+// jwtHeader is the protected header of a compact JWS
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// parseJWT splits a compact JWT, returning its header, raw claims, signing input and signature
+func parseJWT(token string) (*jwtHeader, []byte, []byte, []byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, nil, nil, errors.New("malformed JWT")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("invalid JWT header: %w", err)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("invalid JWT header: %w", err)
+	}
+
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("invalid JWT signature: %w", err)
+	}
+
+	return &header, claims, []byte(parts[0] + "." + parts[1]), sig, nil
+}
+
+// verifySignature checks a JWS signature; only RS256 and ES256 are accepted
+func verifySignature(alg string, key crypto.PublicKey, signingInput, sig []byte) error {
+	digest := sha256.Sum256(signingInput)
+
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("RS256 token signed with a non-RSA key")
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("ES256 token signed with a non-EC key")
+		}
+		if len(sig) != 64 {
+			return errors.New("invalid ES256 signature length")
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return errors.New("invalid ES256 signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+}
+
+// idTokenClaims are the registered claims checked during verification
+type idTokenClaims struct {
+	Issuer    string      `json:"iss"`
+	Subject   string      `json:"sub"`
+	Audience  interface{} `json:"aud"` // string or array of strings
+	ExpiresAt int64       `json:"exp"`
+	IssuedAt  int64       `json:"iat"`
+	NotBefore int64       `json:"nbf"`
+	Nonce     string      `json:"nonce"`
+}
+
+// hasAudience reports whether the aud claim contains clientID
+func (claims idTokenClaims) hasAudience(clientID string) bool {
+	switch aud := claims.Audience.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, value := range aud {
+			if value == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validate checks issuer, audience, lifetime and nonce of an ID token
+func (claims idTokenClaims) validate(issuer, clientID, nonce string, now time.Time) error {
+	if claims.Issuer != issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if !claims.hasAudience(clientID) {
+		return errors.New("token was not issued for this client")
+	}
+	if claims.Subject == "" {
+		return errors.New("token has no subject")
+	}
+	if claims.ExpiresAt == 0 || now.Add(-clockSkew).Unix() > claims.ExpiresAt {
+		return errors.New("token expired")
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Unix() < claims.NotBefore {
+		return errors.New("token not yet valid")
+	}
+	if claims.Nonce != nonce {
+		return errors.New("nonce mismatch")
+	}
+	return nil
+}
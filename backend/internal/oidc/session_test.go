@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testClient() *Client {
+	return NewClient(&Config{SessionSecret: "0123456789abcdef0123456789abcdef", SessionTTL: time.Hour})
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	c := testClient()
+	cookie, err := c.EncodeSession(&Session{
+		Subject:   "sub-1",
+		Username:  "alice",
+		Groups:    []string{"ops"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	session, err := c.DecodeSession(cookie)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if session.Subject != "sub-1" || session.Username != "alice" || len(session.Groups) != 1 {
+		t.Fatalf("decoded %+v", session)
+	}
+}
+
+func TestDecodeSessionRejects(t *testing.T) {
+	c := testClient()
+	live := time.Now().Add(time.Hour).Unix()
+	encode := func(session Session) string {
+		cookie, err := c.EncodeSession(&session)
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		return cookie
+	}
+	valid := encode(Session{Subject: "sub-1", Username: "alice", ExpiresAt: live})
+	payload, sig, _ := strings.Cut(valid, ".")
+	otherSecret := NewClient(&Config{SessionSecret: strings.Repeat("x", 32)})
+	foreign, _ := otherSecret.EncodeSession(&Session{Subject: "sub-1", Username: "alice", ExpiresAt: live})
+
+	// A flow cookie carrying session fields must not pass as a session
+	flow, err := sign(c.cfg.SessionSecret, purposeFlow, Session{Subject: "sub-1", Username: "alice", ExpiresAt: live})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	for name, cookie := range map[string]string{
+		"empty":          "",
+		"unsigned":       payload,
+		"tampered":       payload + "A." + sig,
+		"bad signature":  payload + "." + strings.Repeat("A", len(sig)),
+		"other secret":   foreign,
+		"flow purpose":   flow,
+		"expired":        encode(Session{Subject: "sub-1", Username: "alice", ExpiresAt: time.Now().Add(-time.Second).Unix()}),
+		"no subject":     encode(Session{Username: "alice", ExpiresAt: live}),
+		"blank username": encode(Session{Subject: "sub-1", Username: "  ", ExpiresAt: live}),
+	} {
+		if _, err := c.DecodeSession(cookie); err == nil {
+			t.Errorf("%s: session accepted", name)
+		}
+	}
+}
+
+func TestFinishLoginRejectsSessionAsFlow(t *testing.T) {
+	c := testClient()
+	session, err := c.EncodeSession(&Session{Subject: "sub-1", Username: "alice", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	if _, _, err := c.FinishLogin(context.Background(), session, "", "code"); err == nil || !strings.Contains(err.Error(), "login flow") {
+		t.Fatalf("FinishLogin error = %v, want login flow error", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package propagation verifies that record changes are visible in DNS by
+// querying a zone's authoritative nameservers and a set of resolvers.
+package propagation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"dnsmesh/internal/auth"
+	"dnsmesh/internal/models"
+)
+
+// Propagation statuses stored on records
+const (
+	StatusPending    = "pending"
+	StatusPropagated = "propagated"
+	StatusTimeout    = "timeout"
+)
+
+// Config controls propagation checks
+type Config struct {
+	Enabled      bool
+	Resolvers    []string // recursive resolvers as host:port
+	Nameservers  []string // overrides the zone's NS lookup when set, as host:port
+	Timeout      time.Duration
+	Interval     time.Duration
+	QueryTimeout time.Duration
+}
+
+// ConfigFromEnv reads PROPAGATION_CHECK, PROPAGATION_RESOLVERS,
+// PROPAGATION_NAMESERVERS, PROPAGATION_TIMEOUT and PROPAGATION_INTERVAL
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Enabled:      auth.IsTruthy(os.Getenv("PROPAGATION_CHECK")),
+		Resolvers:    withDefaultPort(auth.SplitList(os.Getenv("PROPAGATION_RESOLVERS"))),
+		Nameservers:  withDefaultPort(auth.SplitList(os.Getenv("PROPAGATION_NAMESERVERS"))),
+		Timeout:      5 * time.Minute,
+		Interval:     10 * time.Second,
+		QueryTimeout: 3 * time.Second,
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("PROPAGATION_TIMEOUT")); err == nil && timeout > 0 {
+		cfg.Timeout = timeout
+	}
+	if interval, err := time.ParseDuration(os.Getenv("PROPAGATION_INTERVAL")); err == nil && interval > 0 {
+		cfg.Interval = interval
+	}
+
+	return cfg
+}
+
+// ServerResult is what one nameserver or resolver answered
+type ServerResult struct {
+	Server        string   `json:"server"`
+	Authoritative bool     `json:"authoritative"`
+	Values        []string `json:"values"`
+	Visible       bool     `json:"visible"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// Result is the outcome of a propagation check
+type Result struct {
+	Status  string         `json:"status"`
+	Servers []ServerResult `json:"servers"`
+}
+
+// Check queries every server once and reports whether the record's value is
+// visible on all of them
+func Check(ctx context.Context, cfg Config, record *models.DNSRecord) Result {
+	result := Result{Status: StatusPropagated}
+
+	nameservers, err := authoritativeServers(ctx, cfg, record.ZoneName)
+	if err != nil {
+		result.Status = StatusPending
+		result.Servers = append(result.Servers, ServerResult{Server: record.ZoneName + " NS", Authoritative: true, Error: err.Error()})
+	}
+
+	check := func(server string, authoritative bool) {
+		queryCtx, cancel := context.WithTimeout(ctx, cfg.QueryTimeout)
+		defer cancel()
+
+		values, err := Query(queryCtx, server, record.FullDomain, record.RecordType, !authoritative)
+		serverResult := ServerResult{Server: server, Authoritative: authoritative, Values: values}
+		if err != nil {
+			serverResult.Error = err.Error()
+		} else {
+			serverResult.Visible = containsValue(record.RecordType, values, record.TargetValue)
+		}
+		if !serverResult.Visible {
+			result.Status = StatusPending
+		}
+		result.Servers = append(result.Servers, serverResult)
+	}
+
+	for _, server := range nameservers {
+		check(server, true)
+	}
+	for _, server := range cfg.Resolvers {
+		check(server, false)
+	}
+
+	return result
+}
+
+// Wait repeats Check every interval until the record is visible everywhere or
+// the timeout passes, and returns the last result
+func Wait(ctx context.Context, cfg Config, record *models.DNSRecord) Result {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	for {
+		result := Check(ctx, cfg, record)
+		if result.Status == StatusPropagated {
+			return result
+		}
+
+		select {
+		case <-ctx.Done():
+			result.Status = StatusTimeout
+			return result
+		case <-time.After(cfg.Interval):
+		}
+	}
+}
+
+// authoritativeServers returns the configured nameservers, or looks up the zone's NS records
+func authoritativeServers(ctx context.Context, cfg Config, zone string) ([]string, error) {
+	if len(cfg.Nameservers) > 0 {
+		return cfg.Nameservers, nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, cfg.QueryTimeout)
+	defer cancel()
+
+	records, err := net.DefaultResolver.LookupNS(lookupCtx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up nameservers of %s: %w", zone, err)
+	}
+
+	servers := make([]string, 0, len(records))
+	for _, ns := range records {
+		servers = append(servers, net.JoinHostPort(strings.TrimSuffix(ns.Host, "."), "53"))
+	}
+	return servers, nil
+}
+
+// containsValue reports whether want is among the answered values
+func containsValue(recordType string, values []string, want string) bool {
+	for _, value := range values {
+		switch recordType {
+		case models.RecordTypeA, models.RecordTypeAAAA:
+			if ip := net.ParseIP(value); ip != nil && ip.Equal(net.ParseIP(want)) {
+				return true
+			}
+		case models.RecordTypeCNAME:
+			if auth.NormalizeDomain(value) == auth.NormalizeDomain(want) {
+				return true
+			}
+		default:
+			if value == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withDefaultPort appends port 53 to servers given without one
+func withDefaultPort(servers []string) []string {
+	for i, server := range servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			servers[i] = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+		}
+	}
+	return servers
+}
@@ -0,0 +1,256 @@
+package propagation
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dnsmesh/internal/models"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testServer is a UDP nameserver answering from a mutable record set
+type testServer struct {
+	conn net.PacketConn
+
+	mu        sync.Mutex
+	answers   map[string][]string // "name/TYPE" to values
+	queries   int
+	recursion []bool // RecursionDesired of each query
+}
+
+func startTestServer(t *testing.T) *testServer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &testServer{conn: conn, answers: make(map[string][]string)}
+	t.Cleanup(func() { conn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *testServer) addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// set replaces the values answered for name and recordType
+func (s *testServer) set(name, recordType string, values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.answers[strings.ToLower(name)+"/"+recordType] = values
+}
+
+func (s *testServer) queryCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries
+}
+
+// lastRecursion reports whether the latest query asked for recursion
+func (s *testServer) lastRecursion() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recursion[len(s.recursion)-1]
+}
+
+func (s *testServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, peer, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var query dnsmessage.Message
+		if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+			continue
+		}
+		if response, err := s.answer(query); err == nil {
+			s.conn.WriteTo(response, peer)
+		}
+	}
+}
+
+func (s *testServer) answer(query dnsmessage.Message) ([]byte, error) {
+	question := query.Questions[0]
+	name := strings.TrimSuffix(strings.ToLower(question.Name.String()), ".")
+
+	s.mu.Lock()
+	s.queries++
+	s.recursion = append(s.recursion, query.Header.RecursionDesired)
+	var recordType string
+	for rt, qtype := range queryTypes {
+		if qtype == question.Type {
+			recordType = rt
+		}
+	}
+	values, found := s.answers[name+"/"+recordType]
+	s.mu.Unlock()
+
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.Header.ID, Response: true, Authoritative: true},
+		Questions: query.Questions,
+	}
+	if !found {
+		response.Header.RCode = dnsmessage.RCodeNameError
+		return response.Pack()
+	}
+
+	header := dnsmessage.ResourceHeader{Name: question.Name, Type: question.Type, Class: dnsmessage.ClassINET, TTL: 60}
+	for _, value := range values {
+		var body dnsmessage.ResourceBody
+		switch question.Type {
+		case dnsmessage.TypeA:
+			a := &dnsmessage.AResource{}
+			copy(a.A[:], net.ParseIP(value).To4())
+			body = a
+		case dnsmessage.TypeAAAA:
+			aaaa := &dnsmessage.AAAAResource{}
+			copy(aaaa.AAAA[:], net.ParseIP(value).To16())
+			body = aaaa
+		case dnsmessage.TypeCNAME:
+			body = &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(value + ".")}
+		case dnsmessage.TypeTXT:
+			body = &dnsmessage.TXTResource{TXT: []string{value}}
+		}
+		response.Answers = append(response.Answers, dnsmessage.Resource{Header: header, Body: body})
+	}
+	return response.Pack()
+}
+
+func testConfig(nameserver, resolver *testServer) Config {
+	return Config{
+		Enabled:      true,
+		Nameservers:  []string{nameserver.addr()},
+		Resolvers:    []string{resolver.addr()},
+		Timeout:      time.Second,
+		Interval:     20 * time.Millisecond,
+		QueryTimeout: 200 * time.Millisecond,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	nameserver := startTestServer(t)
+	resolver := startTestServer(t)
+	cfg := testConfig(nameserver, resolver)
+	record := &models.DNSRecord{FullDomain: "www.example.com", ZoneName: "example.com", RecordType: models.RecordTypeA, TargetValue: "192.0.2.10"}
+
+	// Not yet served anywhere
+	result := Check(context.Background(), cfg, record)
+	if result.Status != StatusPending || len(result.Servers) != 2 {
+		t.Fatalf("missing record: %+v, want pending from 2 servers", result)
+	}
+
+	// Authoritative server updated, resolver still serving the old value
+	nameserver.set("www.example.com", models.RecordTypeA, "192.0.2.10")
+	resolver.set("www.example.com", models.RecordTypeA, "192.0.2.99")
+	result = Check(context.Background(), cfg, record)
+	if result.Status != StatusPending {
+		t.Fatalf("stale resolver: status = %s, want pending", result.Status)
+	}
+	if !result.Servers[0].Visible || !result.Servers[0].Authoritative || result.Servers[1].Visible {
+		t.Fatalf("stale resolver: servers = %+v", result.Servers)
+	}
+
+	resolver.set("www.example.com", models.RecordTypeA, "192.0.2.99", "192.0.2.10")
+	result = Check(context.Background(), cfg, record)
+	if result.Status != StatusPropagated {
+		t.Fatalf("both updated: %+v, want propagated", result)
+	}
+
+	// Authoritative servers are asked without recursion, resolvers with it
+	if nameserver.lastRecursion() || !resolver.lastRecursion() {
+		t.Fatalf("recursion desired: nameserver %v, resolver %v", nameserver.lastRecursion(), resolver.lastRecursion())
+	}
+}
+
+func TestCheckRecordTypes(t *testing.T) {
+	server := startTestServer(t)
+	cfg := testConfig(server, server)
+	server.set("alias.example.com", models.RecordTypeCNAME, "Target.Example.net")
+	server.set("v6.example.com", models.RecordTypeAAAA, "2001:db8::1")
+	server.set("_acme-challenge.example.com", models.RecordTypeTXT, "token-value")
+
+	for _, record := range []models.DNSRecord{
+		{FullDomain: "alias.example.com", RecordType: models.RecordTypeCNAME, TargetValue: "target.example.net."},
+		{FullDomain: "v6.example.com", RecordType: models.RecordTypeAAAA, TargetValue: "2001:0db8:0:0:0:0:0:1"},
+		{FullDomain: "_acme-challenge.example.com", RecordType: models.RecordTypeTXT, TargetValue: "token-value"},
+	} {
+		record.ZoneName = "example.com"
+		if result := Check(context.Background(), cfg, &record); result.Status != StatusPropagated {
+			t.Errorf("%s %s: %+v, want propagated", record.RecordType, record.FullDomain, result)
+		}
+	}
+}
+
+func TestCheckUnreachableServer(t *testing.T) {
+	server := startTestServer(t)
+	server.set("www.example.com", models.RecordTypeA, "192.0.2.10")
+
+	// A server that never answers
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer silent.Close()
+
+	cfg := testConfig(server, server)
+	cfg.Resolvers = []string{silent.LocalAddr().String()}
+	record := &models.DNSRecord{FullDomain: "www.example.com", ZoneName: "example.com", RecordType: models.RecordTypeA, TargetValue: "192.0.2.10"}
+
+	result := Check(context.Background(), cfg, record)
+	if result.Status != StatusPending || result.Servers[1].Error == "" {
+		t.Fatalf("silent resolver: %+v, want pending with an error", result)
+	}
+}
+
+func TestWaitPropagates(t *testing.T) {
+	nameserver := startTestServer(t)
+	resolver := startTestServer(t)
+	cfg := testConfig(nameserver, resolver)
+	nameserver.set("www.example.com", models.RecordTypeA, "192.0.2.10")
+	record := &models.DNSRecord{FullDomain: "www.example.com", ZoneName: "example.com", RecordType: models.RecordTypeA, TargetValue: "192.0.2.10"}
+
+	// The resolver picks the change up after a few polls
+	go func() {
+		for resolver.queryCount() < 3 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		resolver.set("www.example.com", models.RecordTypeA, "192.0.2.10")
+	}()
+
+	result := Wait(context.Background(), cfg, record)
+	if result.Status != StatusPropagated {
+		t.Fatalf("Wait = %+v, want propagated", result)
+	}
+	if resolver.queryCount() < 3 {
+		t.Fatalf("resolver queried %d times, want at least 3", resolver.queryCount())
+	}
+}
+
+func TestWaitTimesOut(t *testing.T) {
+	nameserver := startTestServer(t)
+	resolver := startTestServer(t)
+	cfg := testConfig(nameserver, resolver)
+	cfg.Timeout = 150 * time.Millisecond
+	nameserver.set("www.example.com", models.RecordTypeA, "192.0.2.10")
+	resolver.set("www.example.com", models.RecordTypeA, "192.0.2.99")
+	record := &models.DNSRecord{FullDomain: "www.example.com", ZoneName: "example.com", RecordType: models.RecordTypeA, TargetValue: "192.0.2.10"}
+
+	start := time.Now()
+	result := Wait(context.Background(), cfg, record)
+	if result.Status != StatusTimeout {
+		t.Fatalf("Wait = %+v, want timeout", result)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Wait took %s, want about %s", elapsed, cfg.Timeout)
+	}
+	if len(result.Servers) != 2 || !result.Servers[0].Visible || result.Servers[1].Visible {
+		t.Fatalf("Wait servers = %+v, want the last check's results", result.Servers)
+	}
+}
@@ -0,0 +1,112 @@
+package propagation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"dnsmesh/internal/models"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// queryTypes maps record types to DNS query types
+var queryTypes = map[string]dnsmessage.Type{
+	models.RecordTypeA:     dnsmessage.TypeA,
+	models.RecordTypeAAAA:  dnsmessage.TypeAAAA,
+	models.RecordTypeCNAME: dnsmessage.TypeCNAME,
+	models.RecordTypeTXT:   dnsmessage.TypeTXT,
+}
+
+// Query asks server for the records of name and type recordType and returns
+// their values. Authoritative servers are queried without recursion, so they
+// answer from their own zone data rather than a cache.
+func Query(ctx context.Context, server, name, recordType string, recursive bool) ([]string, error) {
+	qtype, ok := queryTypes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type %s", recordType)
+	}
+
+	qname, err := dnsmessage.NewName(strings.TrimSuffix(name, ".") + ".")
+	if err != nil {
+		return nil, fmt.Errorf("invalid name %s: %w", name, err)
+	}
+
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, err
+	}
+	id := binary.BigEndian.Uint16(idBytes[:])
+
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: recursive},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		var response dnsmessage.Message
+		if err := response.Unpack(buf[:n]); err != nil || response.Header.ID != id {
+			continue // not our answer, keep waiting until the deadline
+		}
+		if response.Header.RCode == dnsmessage.RCodeNameError {
+			return nil, nil
+		}
+		if response.Header.RCode != dnsmessage.RCodeSuccess {
+			return nil, fmt.Errorf("server answered %s", response.Header.RCode)
+		}
+		if response.Header.Truncated {
+			return nil, errors.New("truncated response")
+		}
+
+		return answerValues(response.Answers, qname, qtype), nil
+	}
+}
+
+// answerValues extracts the values of the answers to qname with type qtype
+func answerValues(answers []dnsmessage.Resource, qname dnsmessage.Name, qtype dnsmessage.Type) []string {
+	var values []string
+	for _, answer := range answers {
+		if answer.Header.Type != qtype || !strings.EqualFold(answer.Header.Name.String(), qname.String()) {
+			continue
+		}
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			values = append(values, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			values = append(values, net.IP(body.AAAA[:]).String())
+		case *dnsmessage.CNAMEResource:
+			values = append(values, strings.TrimSuffix(body.CNAME.String(), "."))
+		case *dnsmessage.TXTResource:
+			values = append(values, strings.Join(body.TXT, ""))
+		}
+	}
+	return values
+}
@@ -31,7 +31,7 @@ func AnalyzeDNSRecords(records []DNSRecordSync) AnalysisResult {
 	var suggestions []ServerSuggestion
 
 	// Build maps for analysis
-	cnameTargetMap := make(map[string][]string) // target -> []sources
+	cnameTargetMap := cnameTargets(records)     // target -> []sources
 	ipMap := make(map[string][]string)          // ip -> []domains
 	domainMap := make(map[string]DNSRecordSync) // domain -> record
 
@@ -39,17 +39,7 @@ func AnalyzeDNSRecords(records []DNSRecordSync) AnalysisResult {
 	for _, record := range records {
 		domainMap[record.FullDomain] = record
 
-		if record.RecordType == models.RecordTypeCNAME {
-			// Normalize trailing dot for CNAME targets (FQDN format)
-			target := record.TargetValue
-			if len(target) > 0 && target[len(target)-1] == '.' {
-				target = target[:len(target)-1]
-			}
-			cnameTargetMap[target] = append(
-				cnameTargetMap[target],
-				record.FullDomain,
-			)
-		} else if record.RecordType == models.RecordTypeA {
+		if record.RecordType == models.RecordTypeA {
 			ipMap[record.TargetValue] = append(
 				ipMap[record.TargetValue],
 				record.FullDomain,
@@ -179,9 +169,10 @@ func AnalyzeDNSRecords(records []DNSRecordSync) AnalysisResult {
 	}
 }
 
-// ServerGroup represents a server record with its related records
+// ServerGroup represents a server's hostname record with its related records
 type ServerGroup struct {
 	Server         models.DNSRecord   `json:"server"`
+	Info           *models.Server     `json:"server_info,omitempty"`
 	RelatedRecords []models.DNSRecord `json:"related_records"`
 }
 
@@ -197,11 +188,15 @@ type GroupedRecords struct {
 	Servers              []ServerGroup                 `json:"servers"`
 	UnassignedRecords    []UnassignedGroup             `json:"unassigned_records"`
 	ProviderCapabilities map[uint]ProviderCapabilities `json:"provider_capabilities"`
+	Total                int64                         `json:"total"`    // records matching the query
+	HasMore              bool                          `json:"has_more"` // more pages follow
 }
 
-// GroupRecords groups DNS records by server first, then unassigned records by provider
-// This supports cross-provider analysis where CNAMEs can point across providers
-func GroupRecords(records []models.DNSRecord, providers []models.Provider) GroupedRecords {
+// GroupRecords groups DNS records by the server they are linked to, then
+// unassigned records by provider. Each group is headed by the server's best
+// hostname record; servers without a managed hostname record are left out
+// and their records listed as unassigned.
+func GroupRecords(records []models.DNSRecord, providers []models.Provider, servers []models.Server) GroupedRecords {
 	providerMap := make(map[uint]models.Provider)
 	for _, p := range providers {
 		providerMap[p.ID] = p
@@ -212,116 +207,41 @@ func GroupRecords(records []models.DNSRecord, providers []models.Provider) Group
 		capabilities[p.ID] = GetProviderCapabilities(p)
 	}
 
-	// Separate ALL servers and non-servers across all providers
-	var allServers []models.DNSRecord
-	var allOtherRecords []models.DNSRecord
-	recordUsed := make(map[uint]bool) // Track which records have been assigned
+	serverMap := make(map[uint]models.Server)
+	for _, server := range servers {
+		serverMap[server.ID] = server
+	}
 
+	// Collect the records of each server, across all providers
+	linked := make(map[uint][]models.DNSRecord)
+	var unassigned []models.DNSRecord
 	for _, record := range records {
-		if record.IsServer {
-			allServers = append(allServers, record)
-		} else {
-			allOtherRecords = append(allOtherRecords, record)
+		if record.ServerID != nil {
+			if _, ok := serverMap[*record.ServerID]; ok {
+				linked[*record.ServerID] = append(linked[*record.ServerID], record)
+				continue
+			}
 		}
-	}
-
-	// Group servers by IP to merge duplicates
-	serversByIP := make(map[string][]models.DNSRecord)
-	for _, server := range allServers {
-		serversByIP[server.TargetValue] = append(serversByIP[server.TargetValue], server)
+		unassigned = append(unassigned, record)
 	}
 
 	// Build server groups (top level)
 	var serverGroups []ServerGroup
-
-	// For each IP, select the best primary server and merge others
-	for _, serversWithSameIP := range serversByIP {
-		// Select primary server: prefer region-formatted domain with server_name
-		var primaryServer models.DNSRecord
-		var otherServers []models.DNSRecord
-
-		// Sort to find best primary: prefer region-formatted domains
-		bestScore := -1
-		for _, server := range serversWithSameIP {
-			score := 0
-
-			// Check if domain matches region-number format (highest priority)
-			// and the region code is valid (exists in RegionMap)
-			matches := serverPattern.FindStringSubmatch(server.FullDomain)
-			if len(matches) > 1 {
-				regionCode := matches[1]
-				if _, exists := RegionMap[regionCode]; exists {
-					score += 10
-				}
-			}
-
-			// Additional points for server metadata
-			if server.ServerName != "" {
-				score += 2
-			}
-			if server.ServerRegion != "" {
-				score += 1
-			}
-
-			if score > bestScore {
-				if bestScore >= 0 {
-					otherServers = append(otherServers, primaryServer)
-				}
-				primaryServer = server
-				bestScore = score
-			} else {
-				otherServers = append(otherServers, server)
-			}
+	for serverID, serverRecords := range linked {
+		primary := PrimaryServerRecord(serverRecords)
+		if primary < 0 {
+			unassigned = append(unassigned, serverRecords...)
+			continue
 		}
 
+		info := serverMap[serverID]
 		serverGroup := ServerGroup{
-			Server: primaryServer,
+			Server: serverRecords[primary],
+			Info:   &info,
 		}
-
-		// Add other same-IP servers as related A records
-		for _, otherServer := range otherServers {
-			serverGroup.RelatedRecords = append(serverGroup.RelatedRecords, otherServer)
-			recordUsed[otherServer.ID] = true
-		}
-
-		// Find records that point to this server (CNAME or same IP) from ALL providers
-		for _, rec := range allOtherRecords {
-			if recordUsed[rec.ID] {
-				continue // Already assigned to another server
-			}
-
-			isRelated := false
-
-			// Check if CNAME points to this primary server or any merged server
-			if rec.RecordType == models.RecordTypeCNAME {
-				target := rec.TargetValue
-				// Remove trailing dot if present
-				if len(target) > 0 && target[len(target)-1] == '.' {
-					target = target[:len(target)-1]
-				}
-
-				// Check if points to primary server
-				if target == primaryServer.FullDomain {
-					isRelated = true
-				} else {
-					// Check if points to any of the merged servers
-					for _, otherServer := range otherServers {
-						if target == otherServer.FullDomain {
-							isRelated = true
-							break
-						}
-					}
-				}
-			}
-
-			// Check if A record points to same IP (shouldn't happen as they're already merged, but keep for safety)
-			if rec.RecordType == models.RecordTypeA && rec.TargetValue == primaryServer.TargetValue {
-				isRelated = true
-			}
-
-			if isRelated {
-				serverGroup.RelatedRecords = append(serverGroup.RelatedRecords, rec)
-				recordUsed[rec.ID] = true
+		for i, record := range serverRecords {
+			if i != primary {
+				serverGroup.RelatedRecords = append(serverGroup.RelatedRecords, record)
 			}
 		}
 
@@ -332,10 +252,8 @@ func GroupRecords(records []models.DNSRecord, providers []models.Provider) Group
 	var unassignedGroups []UnassignedGroup
 	providerUnassigned := make(map[uint][]models.DNSRecord)
 
-	for _, rec := range allOtherRecords {
-		if !recordUsed[rec.ID] {
-			providerUnassigned[rec.ProviderID] = append(providerUnassigned[rec.ProviderID], rec)
-		}
+	for _, rec := range unassigned {
+		providerUnassigned[rec.ProviderID] = append(providerUnassigned[rec.ProviderID], rec)
 	}
 
 	// Convert map to array
@@ -381,13 +299,13 @@ func GroupRecords(records []models.DNSRecord, providers []models.Provider) Group
 
 // GetProviderCapabilities returns capability flags for a provider
 func GetProviderCapabilities(provider models.Provider) ProviderCapabilities {
-	supportsStatusToggle := false
 	switch provider.Name {
+	case models.ProviderCloudflare:
+		// TTL 1 means automatic; 60 is the minimum below the Enterprise plan
+		return ProviderCapabilities{MinTTL: 60, MaxTTL: 86400, AutoTTL: 1}
 	case models.ProviderTencentCloud:
-		supportsStatusToggle = true
-	}
-
-	return ProviderCapabilities{
-		SupportsRecordStatusToggle: supportsStatusToggle,
+		// 600 is the minimum on the free DNSPod plan
+		return ProviderCapabilities{SupportsRecordStatusToggle: true, MinTTL: 600, MaxTTL: 604800}
 	}
+	return ProviderCapabilities{}
 }
@@ -85,13 +85,14 @@ func (s *CloudflareService) SyncRecords() ([]DNSRecordSync, error) {
 			}
 
 			recordType := record.Type
-			if recordType == models.RecordTypeA && record.Content != "" && net.ParseIP(record.Content) == nil {
+			if (recordType == models.RecordTypeA || recordType == models.RecordTypeAAAA) && record.Content != "" && net.ParseIP(record.Content) == nil {
 				priority := ""
 				if record.Priority != nil {
 					priority = fmt.Sprintf("%d", *record.Priority)
 				}
 				log.Printf(
-					"Cloudflare Sync: A record has non-IP content; skipping (zone=%s name=%s content=%s id=%s priority=%s)",
+					"Cloudflare Sync: %s record has non-IP content; skipping (zone=%s name=%s content=%s id=%s priority=%s)",
+					recordType,
 					zone.Name,
 					record.Name,
 					record.Content,
@@ -101,8 +102,8 @@ func (s *CloudflareService) SyncRecords() ([]DNSRecordSync, error) {
 				continue
 			}
 
-			// Only sync A and CNAME records
-			if recordType != models.RecordTypeA && recordType != models.RecordTypeCNAME {
+			// Only sync A, AAAA and CNAME records
+			if recordType != models.RecordTypeA && recordType != models.RecordTypeAAAA && recordType != models.RecordTypeCNAME {
 				if recordType == "MX" {
 					priority := ""
 					if record.Priority != nil {
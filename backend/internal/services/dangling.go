@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"dnsmesh/internal/models"
+	"errors"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of dangling CNAME findings
+const (
+	DanglingMissingTarget        = "missing_target"         // target is inside a synced zone but has no record
+	DanglingUnmanagedTarget      = "unmanaged_target"       // target exists in a synced zone but is not managed
+	DanglingThirdPartyUnresolved = "third_party_unresolved" // target at a hosting service no longer resolves
+	DanglingExternalUnresolved   = "external_unresolved"    // target outside the synced zones no longer resolves
+)
+
+// Severities of dangling CNAME findings
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// DanglingFinding is a CNAME whose target may be gone, leaving the name open
+// to subdomain takeover
+type DanglingFinding struct {
+	RecordID   uint   `json:"record_id,omitempty"`
+	ZoneName   string `json:"zone_name"`
+	Domain     string `json:"domain"`
+	Target     string `json:"target"`
+	Kind       string `json:"kind"`
+	Severity   string `json:"severity"`
+	Service    string `json:"service,omitempty"` // hosting service of the target
+	Reason     string `json:"reason"`
+	ResolveErr string `json:"resolve_error,omitempty"`
+}
+
+// HostResolver reports whether host resolves. A NXDOMAIN or empty answer is
+// (false, nil); an error means the answer is unknown.
+type HostResolver func(host string) (bool, error)
+
+// thirdPartyServices are hosting services whose names can be claimed by
+// anyone once the resource behind a CNAME is deleted
+var thirdPartyServices = []struct {
+	Name    string
+	Pattern *regexp.Regexp
+}{
+	{"AWS S3", regexp.MustCompile(`(^|\.)s3([.-][a-z0-9-]+)*\.amazonaws\.com$`)},
+	{"GitHub Pages", regexp.MustCompile(`\.github\.io$`)},
+	{"Heroku", regexp.MustCompile(`\.(herokuapp|herokudns|herokussl)\.com$`)},
+	{"Azure", regexp.MustCompile(`\.(azurewebsites\.net|cloudapp\.net|cloudapp\.azure\.com|trafficmanager\.net|blob\.core\.windows\.net|azureedge\.net|azurefd\.net|azure-api\.net)$`)},
+}
+
+// danglingLookupWorkers limits concurrent lookups of external targets
+const danglingLookupWorkers = 8
+
+// normalizeTarget lowercases a CNAME target and strips its trailing dot
+func normalizeTarget(target string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(target), "."))
+}
+
+// cnameTargets maps each CNAME target to the domains pointing at it
+func cnameTargets(records []DNSRecordSync) map[string][]string {
+	targets := make(map[string][]string)
+	for _, record := range records {
+		if record.RecordType == models.RecordTypeCNAME {
+			target := normalizeTarget(record.TargetValue)
+			targets[target] = append(targets[target], record.FullDomain)
+		}
+	}
+	return targets
+}
+
+// thirdPartyService names the hosting service of host, if any
+func thirdPartyService(host string) string {
+	for _, service := range thirdPartyServices {
+		if service.Pattern.MatchString(host) {
+			return service.Name
+		}
+	}
+	return ""
+}
+
+// ResolveHost looks host up with the system resolver
+func ResolveHost(host string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(addrs) > 0, nil
+}
+
+// FindDanglingCNAMEs checks the CNAMEs among records, all records of the
+// synced zones, for targets that are gone. managed holds the domains managed
+// by dnsMesh. Targets outside the synced zones are looked up with resolve;
+// a nil resolve skips those lookups.
+func FindDanglingCNAMEs(records []DNSRecordSync, managed map[string]bool, resolve HostResolver) []DanglingFinding {
+	zones := make(map[string]bool)
+	existing := make(map[string]bool)
+	for _, record := range records {
+		zones[strings.ToLower(record.ZoneName)] = true
+		existing[strings.ToLower(record.FullDomain)] = true
+	}
+
+	inZone := func(host string) bool {
+		for zone := range zones {
+			if host == zone || strings.HasSuffix(host, "."+zone) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// A wildcard record one level up answers for a missing name
+	coveredByWildcard := func(host string) bool {
+		if _, parent, ok := strings.Cut(host, "."); ok {
+			return existing["*."+parent]
+		}
+		return false
+	}
+
+	targets := cnameTargets(records)
+	var findings []DanglingFinding
+	var external []string
+	for target := range targets {
+		if target == "" {
+			continue
+		}
+		if inZone(target) {
+			if !existing[target] && !coveredByWildcard(target) {
+				findings = append(findings, danglingFindings(records, target, DanglingFinding{
+					Kind:     DanglingMissingTarget,
+					Severity: SeverityMedium,
+					Reason:   "目标不在任何已同步 Zone 的记录中",
+				})...)
+			} else if existing[target] && !managed[target] {
+				findings = append(findings, danglingFindings(records, target, DanglingFinding{
+					Kind:     DanglingUnmanagedTarget,
+					Severity: SeverityLow,
+					Reason:   "目标记录存在但未被 dnsMesh 纳管",
+				})...)
+			}
+			continue
+		}
+		external = append(external, target)
+	}
+
+	if resolve != nil {
+		for target, result := range resolveTargets(external, resolve) {
+			if result.resolved {
+				continue
+			}
+			finding := DanglingFinding{
+				Kind:     DanglingExternalUnresolved,
+				Severity: SeverityMedium,
+				Reason:   "外部目标无法解析，域名可能已过期",
+			}
+			if service := thirdPartyService(target); service != "" {
+				finding.Kind = DanglingThirdPartyUnresolved
+				finding.Severity = SeverityHigh
+				finding.Service = service
+				finding.Reason = service + " 上的目标已不存在，可能被他人接管"
+			}
+			if result.err != nil {
+				// Unknown answers are only reported for hosting services
+				if finding.Service == "" {
+					continue
+				}
+				finding.Severity = SeverityLow
+				finding.ResolveErr = result.err.Error()
+				finding.Reason = finding.Service + " 上的目标解析失败，请人工确认"
+			}
+			findings = append(findings, danglingFindings(records, target, finding)...)
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if rank := severityRank(findings[i].Severity) - severityRank(findings[j].Severity); rank != 0 {
+			return rank < 0
+		}
+		return findings[i].Domain < findings[j].Domain
+	})
+	return findings
+}
+
+// danglingFindings copies finding for every CNAME of records pointing at target
+func danglingFindings(records []DNSRecordSync, target string, finding DanglingFinding) []DanglingFinding {
+	var findings []DanglingFinding
+	for _, record := range records {
+		if record.RecordType != models.RecordTypeCNAME || normalizeTarget(record.TargetValue) != target {
+			continue
+		}
+		f := finding
+		f.ZoneName = record.ZoneName
+		f.Domain = record.FullDomain
+		f.Target = target
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// severityRank orders severities from high to low
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityHigh:
+		return 0
+	case SeverityMedium:
+		return 1
+	default:
+		return 2
+	}
+}
+
+type resolveResult struct {
+	resolved bool
+	err      error
+}
+
+// resolveTargets looks up hosts concurrently
+func resolveTargets(hosts []string, resolve HostResolver) map[string]resolveResult {
+	results := make(map[string]resolveResult, len(hosts))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+
+	for i := 0; i < danglingLookupWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range queue {
+				resolved, err := resolve(host)
+				mu.Lock()
+				results[host] = resolveResult{resolved: resolved, err: err}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, host := range hosts {
+		queue <- host
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
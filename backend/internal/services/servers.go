@@ -0,0 +1,144 @@
+package services
+
+import (
+	"dnsmesh/internal/models"
+	"net"
+	"strings"
+)
+
+// serverScore ranks the hostname records of a server; the highest one heads
+// its group. Region-formatted domains win, then records with server metadata.
+func serverScore(record models.DNSRecord) int {
+	score := 0
+
+	// Check if domain matches region-number format (highest priority)
+	// and the region code is valid (exists in RegionMap)
+	matches := serverPattern.FindStringSubmatch(record.FullDomain)
+	if len(matches) > 1 {
+		if _, exists := RegionMap[matches[1]]; exists {
+			score += 10
+		}
+	}
+
+	// Additional points for server metadata
+	if record.ServerName != "" {
+		score += 2
+	}
+	if record.ServerRegion != "" {
+		score += 1
+	}
+
+	return score
+}
+
+// PrimaryServerRecord returns the index of the best-scoring server record of
+// a server's records, the one heading its group, or -1 if there is none
+func PrimaryServerRecord(records []models.DNSRecord) int {
+	primary := -1
+	bestScore := -1
+	for i, record := range records {
+		if !record.IsServer {
+			continue
+		}
+		if score := serverScore(record); score > bestScore {
+			primary = i
+			bestScore = score
+		}
+	}
+	return primary
+}
+
+// ServerAddresses returns the IPv4 and IPv6 addresses of server
+func ServerAddresses(server models.Server) []string {
+	var addresses []string
+	for _, list := range []string{server.IPv4, server.IPv6} {
+		for _, address := range strings.Split(list, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses
+}
+
+// PlannedServer is a server to create together with the records to link to it
+type PlannedServer struct {
+	Server  models.Server
+	Records []models.DNSRecord
+}
+
+// PlanServers turns server records that belong to no server yet into new
+// servers, one per target. Records sharing a target are merged into one
+// server named after the best of them, as the dashboard used to group them.
+// The target becomes the server's address only if it is an IP of the record
+// type's family.
+func PlanServers(serverRecords []models.DNSRecord) []PlannedServer {
+	byIP := make(map[string][]models.DNSRecord)
+	var order []string
+	for _, record := range serverRecords {
+		if _, ok := byIP[record.TargetValue]; !ok {
+			order = append(order, record.TargetValue)
+		}
+		byIP[record.TargetValue] = append(byIP[record.TargetValue], record)
+	}
+
+	planned := make([]PlannedServer, 0, len(order))
+	for _, ip := range order {
+		records := byIP[ip]
+		primary := records[0]
+		for _, record := range records[1:] {
+			if serverScore(record) > serverScore(primary) {
+				primary = record
+			}
+		}
+
+		server := models.Server{
+			Name:   primary.ServerName,
+			Region: primary.ServerRegion,
+		}
+		if server.Name == "" {
+			server.Name = primary.FullDomain
+		}
+		// Only an address of the record's own family becomes the server's;
+		// CNAMEs and malformed targets just link the records
+		if parsed := net.ParseIP(ip); parsed != nil {
+			switch {
+			case primary.RecordType == models.RecordTypeA && parsed.To4() != nil:
+				server.IPv4 = parsed.String()
+			case primary.RecordType == models.RecordTypeAAAA && parsed.To4() == nil:
+				server.IPv6 = parsed.String()
+			}
+		}
+
+		planned = append(planned, PlannedServer{Server: server, Records: records})
+	}
+	return planned
+}
+
+// ServerForRecord finds the server an unlinked record belongs to: A and AAAA
+// records by one of the server's addresses, CNAMEs by pointing at a hostname
+// record of the server. hostRecords are the linked server records.
+func ServerForRecord(record models.DNSRecord, servers []models.Server, hostRecords []models.DNSRecord) (uint, bool) {
+	switch record.RecordType {
+	case models.RecordTypeA, models.RecordTypeAAAA:
+		ip := net.ParseIP(record.TargetValue)
+		if ip == nil {
+			return 0, false
+		}
+		for _, server := range servers {
+			for _, address := range ServerAddresses(server) {
+				if ip.Equal(net.ParseIP(address)) {
+					return server.ID, true
+				}
+			}
+		}
+	case models.RecordTypeCNAME:
+		target := strings.TrimSuffix(record.TargetValue, ".")
+		for _, host := range hostRecords {
+			if host.ServerID != nil && strings.EqualFold(host.FullDomain, target) {
+				return *host.ServerID, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -67,8 +67,8 @@ func (s *TencentCloudService) SyncRecords() ([]DNSRecordSync, error) {
 		for _, record := range recordListResp.Response.RecordList {
 			recordType := *record.Type
 
-			// Only sync A and CNAME records
-			if recordType != models.RecordTypeA && recordType != models.RecordTypeCNAME {
+			// Only sync A, AAAA and CNAME records
+			if recordType != models.RecordTypeA && recordType != models.RecordTypeAAAA && recordType != models.RecordTypeCNAME {
 				continue
 			}
 
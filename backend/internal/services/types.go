@@ -42,6 +42,9 @@ type AnalysisResult struct {
 // ProviderCapabilities describe optional abilities of a provider
 type ProviderCapabilities struct {
 	SupportsRecordStatusToggle bool `json:"supports_record_status_toggle"`
+	MinTTL                     int  `json:"min_ttl,omitempty"`  // lowest TTL accepted, 0 when unknown
+	MaxTTL                     int  `json:"max_ttl,omitempty"`  // highest TTL accepted, 0 when unknown
+	AutoTTL                    int  `json:"auto_ttl,omitempty"` // special value meaning automatic TTL
 }
 
 // DNSProvider interface for different DNS providers
@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"dnsmesh/internal/events"
+	"dnsmesh/internal/models"
+	"dnsmesh/pkg/crypto"
+
+	"gorm.io/gorm"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-DNSMesh-Event"
+	HeaderDelivery  = "X-DNSMesh-Delivery"
+	HeaderTimestamp = "X-DNSMesh-Timestamp"
+	HeaderSignature = "X-DNSMesh-Signature"
+)
+
+// Config controls delivery behaviour
+type Config struct {
+	MaxAttempts  int
+	Timeout      time.Duration
+	PollInterval time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// ConfigFromEnv reads delivery settings from environment variables
+func ConfigFromEnv() Config {
+	cfg := Config{
+		MaxAttempts:  8,
+		Timeout:      10 * time.Second,
+		PollInterval: 5 * time.Second,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+
+	if attempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		cfg.MaxAttempts = attempts
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT")); err == nil && timeout > 0 {
+		cfg.Timeout = timeout
+	}
+
+	return cfg
+}
+
+// Dispatcher turns bus events into persisted deliveries and sends them
+type Dispatcher struct {
+	db     *gorm.DB
+	cfg    Config
+	client *http.Client
+	wake   chan struct{}
+}
+
+// Start subscribes to the default event bus and starts the delivery worker.
+// Pending deliveries are stored in the database, so retries survive restarts.
+func Start(db *gorm.DB, cfg Config) *Dispatcher {
+	d := &Dispatcher{
+		db:     db,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		wake:   make(chan struct{}, 1),
+	}
+
+	// Block publishers briefly rather than lose events while the database is slow
+	sub, _ := events.SubscribeReliable(1024, 5*time.Second)
+	go d.enqueueLoop(sub)
+	go d.deliverLoop()
+
+	return d
+}
+
+// Sign returns the signature header value for a payload: HMAC-SHA256 over "<timestamp>.<body>"
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Matches reports whether a webhook is subscribed to the given event type
+func Matches(hook *models.Webhook, eventType string) bool {
+	if strings.TrimSpace(hook.EventTypes) == "" {
+		return true
+	}
+	for _, t := range strings.Split(hook.EventTypes, ",") {
+		if strings.TrimSpace(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) enqueueLoop(sub <-chan events.Event) {
+	for event := range sub {
+		if err := d.enqueue(event); err != nil {
+			log.Printf("Webhook: Failed to enqueue event %d (%s): %v", event.ID, event.Type, err)
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(event events.Event) error {
+	var hooks []models.Webhook
+	if err := d.db.Where("active = ?", true).Find(&hooks).Error; err != nil {
+		return err
+	}
+
+	var payload []byte
+	queued := 0
+	for i := range hooks {
+		if !Matches(&hooks[i], event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+
+		delivery := models.WebhookDelivery{
+			WebhookID:     hooks[i].ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: time.Now().UTC(),
+		}
+		if err := d.db.Create(&delivery).Error; err != nil {
+			return err
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) deliverLoop() {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue()
+
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *Dispatcher) deliverDue() {
+	var due []models.WebhookDelivery
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now().UTC()).
+		Order("id ASC").
+		Limit(50).
+		Find(&due).Error; err != nil {
+		log.Printf("Webhook: Failed to load pending deliveries: %v", err)
+		return
+	}
+
+	for i := range due {
+		d.attempt(&due[i])
+	}
+}
+
+func (d *Dispatcher) attempt(delivery *models.WebhookDelivery) {
+	var hook models.Webhook
+	if err := d.db.First(&hook, delivery.WebhookID).Error; err != nil || !hook.Active {
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = "webhook deleted or disabled"
+		d.db.Save(delivery)
+		return
+	}
+
+	delivery.Attempts++
+	statusCode, err := d.send(&hook, delivery)
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		now := time.Now().UTC()
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= d.cfg.MaxAttempts {
+			delivery.Status = models.DeliveryFailed
+			log.Printf("Webhook: Giving up on delivery %d to %s after %d attempts: %v",
+				delivery.ID, hook.URL, delivery.Attempts, err)
+		} else {
+			delivery.NextAttemptAt = time.Now().UTC().Add(d.backoff(delivery.Attempts))
+		}
+	}
+
+	if err := d.db.Save(delivery).Error; err != nil {
+		log.Printf("Webhook: Failed to save delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func (d *Dispatcher) send(hook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	secret, err := crypto.Decrypt(hook.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsMesh-Webhook/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait before the next attempt, doubling after each failure
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.cfg.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return wait
+}
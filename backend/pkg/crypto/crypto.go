@@ -5,6 +5,7 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"io"
 	"os"
@@ -81,3 +82,12 @@ func Decrypt(ciphertext string) (string, error) {
 
 	return string(plaintext), nil
 }
+
+// RandomToken returns a hex-encoded string of n cryptographically random bytes
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -22,10 +22,12 @@ const AuditLogModal = {
   error: '',
   hasMore: false,
   limit: 20,
-  offset: 0,
+  cursor: null,
+  total: 0,
   filters: {
     action: '',
     resourceType: '',
+    actor: '',
   },
 
   oninit() {
@@ -33,7 +35,7 @@ const AuditLogModal = {
   },
 
   resetAndLoad() {
-    this.offset = 0
+    this.cursor = null
     this.logs = []
     this.loadLogs(true)
   },
@@ -46,16 +48,12 @@ const AuditLogModal = {
 
     try {
       const params = {
+        ...this.filterParams(),
         limit: this.limit,
-        offset: this.offset,
       }
 
-      if (this.filters.action) {
-        params.action = this.filters.action
-      }
-
-      if (this.filters.resourceType) {
-        params.resource_type = this.filters.resourceType
+      if (!reset && this.cursor) {
+        params.cursor = this.cursor
       }
 
       const response = await auditLogs.list(params)
@@ -67,11 +65,9 @@ const AuditLogModal = {
         this.logs = this.logs.concat(fetchedLogs)
       }
 
-      this.hasMore = (response.count || 0) === this.limit
-
-      if (this.hasMore) {
-        this.offset += this.limit
-      }
+      this.total = response.total || 0
+      this.hasMore = Boolean(response.has_more)
+      this.cursor = response.next_cursor || null
     } catch (error) {
       console.error('Failed to load audit logs:', error)
       this.hasMore = false
@@ -82,6 +78,24 @@ const AuditLogModal = {
     }
   },
 
+  filterParams() {
+    const params = {}
+
+    if (this.filters.action) {
+      params.action = this.filters.action
+    }
+
+    if (this.filters.resourceType) {
+      params.resource_type = this.filters.resourceType
+    }
+
+    if (this.filters.actor) {
+      params.actor = this.filters.actor
+    }
+
+    return params
+  },
+
   parseDetails(details) {
     if (!details) return '-'
     try {
@@ -94,6 +108,11 @@ const AuditLogModal = {
     }
   },
 
+  formatActor(log) {
+    if (!log.actor) return '-'
+    return log.auth_method ? `${log.actor} (${log.auth_method})` : log.actor
+  },
+
   formatTimestamp(timestamp) {
     if (!timestamp) return '-'
     const date = new Date(timestamp)
@@ -109,6 +128,10 @@ const AuditLogModal = {
       onClose,
       className: 'modal-large',
       footer: [
+        m('span.audit-total', `共 ${this.total} 条`),
+        m('a.btn.btn-secondary.btn-small', {
+          href: auditLogs.exportUrl({ ...this.filterParams(), format: 'csv' }),
+        }, '导出 CSV'),
         m('button.btn.btn-secondary.btn-small', { onclick: onClose }, '关闭')
       ],
     }, [
@@ -137,6 +160,17 @@ const AuditLogModal = {
             m('option', { value: option.value }, option.label)
           )),
         ]),
+        m('div.filter-group', [
+          m('label', '操作人'),
+          m('input[type=text]', {
+            value: this.filters.actor,
+            placeholder: '用户名',
+            onchange: (e) => {
+              this.filters.actor = e.target.value.trim()
+              this.resetAndLoad()
+            },
+          }),
+        ]),
       ]),
 
       this.error && m('.audit-error', this.error),
@@ -153,8 +187,10 @@ const AuditLogModal = {
                   m('th', '动作'),
                   m('th', '资源类型'),
                   m('th', '资源 ID'),
+                  m('th', '操作人'),
                   m('th', '来源 IP'),
                   m('th', '详情'),
+                  m('th', '变更'),
                 ]),
               ]),
               m('tbody', this.logs.map((log) =>
@@ -163,10 +199,14 @@ const AuditLogModal = {
                   m('td', log.action || '-'),
                   m('td', log.resource_type || '-'),
                   m('td', log.resource_id || '-'),
+                  m('td', this.formatActor(log)),
                   m('td', log.ip_address || '-'),
                   m('td', [
                     m('pre.audit-details', this.parseDetails(log.details)),
                   ]),
+                  m('td', [
+                    m('pre.audit-details', this.parseDetails(log.changes)),
+                  ]),
                 ])
               )),
             ]),
@@ -0,0 +1,226 @@
+import m from 'mithril'
+import Modal from './Modal'
+import { servers } from '../services/api'
+
+const ACTION_OPTIONS = [
+  { label: '删除', value: 'delete' },
+  { label: '隐藏', value: 'hide' },
+  { label: '迁移到其他服务器', value: 'repoint' },
+]
+
+const STATUS_LABELS = {
+  planned: '待执行',
+  applied: '已完成',
+  failed: '失败',
+  skipped: '已跳过',
+  rolled_back: '已回滚',
+  rollback_failed: '回滚失败',
+}
+
+const DecommissionModal = {
+  server: null,
+  records: [],
+  healthChecks: [],
+  targets: [],
+  actions: {},
+  deleteServer: true,
+  loading: false,
+  submitting: false,
+  error: '',
+  batch: null,
+
+  oninit(vnode) {
+    this.server = null
+    this.records = []
+    this.healthChecks = []
+    this.targets = []
+    this.actions = {}
+    this.deleteServer = true
+    this.error = ''
+    this.batch = null
+    this.load(vnode.attrs.serverId)
+  },
+
+  async load(serverId) {
+    this.loading = true
+    m.redraw()
+
+    try {
+      const [plan, list] = await Promise.all([
+        servers.decommissionPlan(serverId),
+        servers.list(),
+      ])
+      this.server = plan.server
+      this.records = plan.records || []
+      this.healthChecks = plan.health_checks || []
+      this.targets = (list.servers || []).filter((server) => server.id !== serverId)
+      this.records.forEach((record) => {
+        this.actions[record.id] = { action: 'delete', target_server_id: '' }
+      })
+    } catch (error) {
+      console.error('Failed to load decommission plan:', error)
+      this.error = error.response?.error || error.message || '获取服务器记录失败'
+    } finally {
+      this.loading = false
+      m.redraw()
+    }
+  },
+
+  requestBody(dryRun) {
+    return {
+      actions: this.records.map((record) => {
+        const choice = this.actions[record.id]
+        return {
+          record_id: record.id,
+          action: choice.action,
+          target_server_id: choice.action === 'repoint' ? Number(choice.target_server_id) || 0 : 0,
+        }
+      }),
+      delete_server: this.deleteServer,
+      dry_run: dryRun,
+    }
+  },
+
+  async submit(vnode, dryRun) {
+    if (this.submitting) return
+    if (!dryRun && !confirm('确定要下线此服务器吗？所有记录将作为一个批次执行，失败时自动回滚。')) return
+
+    this.submitting = true
+    this.error = ''
+    m.redraw()
+
+    try {
+      const response = await servers.decommission(this.server.id, this.requestBody(dryRun))
+      this.batch = response.batch
+      if (!dryRun) {
+        vnode.attrs.onComplete && vnode.attrs.onComplete()
+      }
+    } catch (error) {
+      console.error('Failed to decommission server:', error)
+      this.error = error.response?.error || error.message || '下线服务器失败'
+      this.batch = error.response?.batch || null
+    } finally {
+      this.submitting = false
+      m.redraw()
+    }
+  },
+
+  renderRecords() {
+    return m('div.audit-table-wrapper', [
+      m('table.audit-table', [
+        m('thead', [
+          m('tr', [
+            m('th', '域名'),
+            m('th', '类型'),
+            m('th', '目标'),
+            m('th', '操作'),
+          ]),
+        ]),
+        m('tbody', this.records.map((record) => {
+          const choice = this.actions[record.id]
+          return m('tr', { key: record.id }, [
+            m('td', [record.full_domain, record.is_server && m('span', ' 🖥️')]),
+            m('td', record.record_type),
+            m('td', record.target_value),
+            m('td', [
+              m('select', {
+                value: choice.action,
+                onchange: (e) => {
+                  choice.action = e.target.value
+                  this.batch = null
+                },
+              }, ACTION_OPTIONS.map((option) =>
+                m('option', { value: option.value }, option.label)
+              )),
+              choice.action === 'repoint' && m('select', {
+                value: choice.target_server_id,
+                onchange: (e) => {
+                  choice.target_server_id = e.target.value
+                  this.batch = null
+                },
+              }, [
+                m('option', { value: '' }, '选择服务器'),
+                this.targets.map((server) =>
+                  m('option', { value: server.id }, server.name)
+                ),
+              ]),
+            ]),
+          ])
+        })),
+      ]),
+    ])
+  },
+
+  renderBatch() {
+    return m('div.audit-table-wrapper', [
+      m('table.audit-table', [
+        m('thead', [
+          m('tr', [
+            m('th', '操作'),
+            m('th', '域名'),
+            m('th', '变更'),
+            m('th', '状态'),
+          ]),
+        ]),
+        m('tbody', this.batch.results.map((result) =>
+          m('tr', { key: result.index }, [
+            m('td', result.op),
+            m('td', `${result.domain} (${result.record_type})`),
+            m('td', result.to ? `${result.from} → ${result.to}` : result.from),
+            m('td', [
+              STATUS_LABELS[result.status] || result.status,
+              result.error && m('div.audit-error', result.error),
+            ]),
+          ])
+        )),
+      ]),
+    ])
+  },
+
+  view(vnode) {
+    const { onClose } = vnode.attrs
+    const done = this.batch && this.batch.status === 'applied'
+
+    return m(Modal, {
+      title: this.server ? `下线服务器 ${this.server.name}` : '下线服务器',
+      onClose,
+      className: 'modal-large',
+      footer: [
+        !done && m('button.btn.btn-secondary.btn-small', {
+          disabled: this.loading || this.submitting || !this.server,
+          onclick: () => this.submit(vnode, true),
+        }, '预览'),
+        !done && m('button.btn.btn-danger.btn-small', {
+          disabled: this.loading || this.submitting || !this.server,
+          onclick: () => this.submit(vnode, false),
+        }, this.submitting ? '执行中...' : '执行下线'),
+        m('button.btn.btn-secondary.btn-small', { onclick: onClose }, '关闭'),
+      ],
+    }, [
+      this.error && m('.audit-error', this.error),
+
+      this.loading ?
+        m('.audit-loading', '加载中...') :
+        this.server && [
+          this.records.length === 0 ?
+            m('.audit-empty', '此服务器没有关联记录') :
+            this.renderRecords(),
+          this.healthChecks.length > 0 &&
+            m('p', `将同时删除 ${this.healthChecks.length} 个健康检查。`),
+          m('label', [
+            m('input[type=checkbox]', {
+              checked: this.deleteServer,
+              onchange: (e) => { this.deleteServer = e.target.checked },
+            }),
+            ' 完成后删除服务器',
+          ]),
+          this.batch && [
+            m('h4', done ? `批次 ${this.batch.batch_id} 已完成` : '执行计划'),
+            this.renderBatch(),
+          ],
+        ],
+    ])
+  },
+}
+
+export default DecommissionModal
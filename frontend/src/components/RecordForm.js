@@ -1,6 +1,6 @@
 import m from 'mithril'
 import Modal from './Modal'
-import { records } from '../services/api'
+import { records, notifyPendingApproval, notifyLintWarnings } from '../services/api'
 
 const RecordForm = {
   oninit(vnode) {
@@ -26,6 +26,7 @@ const RecordForm = {
     this.targetValue = ''
     this.ttl = 600
     this.notes = ''
+    this.expiresAt = ''
     this.loading = false
     this.error = ''
     this.isEditMode = false
@@ -91,6 +92,7 @@ const RecordForm = {
       this.targetValue = ''
       this.ttl = 600
       this.notes = ''
+      this.expiresAt = ''
       const initialId = context?.serverId ?? this.serverOptions[0]?.id ?? null
       this.selectedTargetServerId = initialId !== null ? Number(initialId) : null
       this.useCustomTarget = this.selectedTargetServerId === null
@@ -149,7 +151,7 @@ const RecordForm = {
             : selectedServer.target_value
         }
 
-        await records.update(record.id, payload)
+        notifyLintWarnings(notifyPendingApproval(await records.update(record.id, payload)))
       } else {
         const payload = {
           full_domain: this.fullDomain,
@@ -159,6 +161,11 @@ const RecordForm = {
           notes: this.notes,
         }
 
+        // Ephemeral records are deleted automatically once they expire
+        if (this.expiresAt) {
+          payload.expires_at = new Date(this.expiresAt).toISOString()
+        }
+
         if (this.useCustomTarget) {
           if (!payload.target_value) {
             this.error = '请输入指向目标'
@@ -184,13 +191,16 @@ const RecordForm = {
           payload.zone_name = selectedServer.zone_name
         }
 
-        await records.create(payload)
+        notifyLintWarnings(notifyPendingApproval(await records.create(payload)))
       }
 
       this.reset(vnode)
       vnode.attrs.onComplete && vnode.attrs.onComplete()
     } catch (error) {
-      this.error = error.response?.error || (this.isEditMode ? '更新失败' : '创建失败')
+      const findings = error.response?.lint
+      this.error = findings?.length
+        ? findings.filter((finding) => finding.severity === 'error').map((finding) => finding.message).join('；')
+        : error.response?.error || (this.isEditMode ? '更新失败' : '创建失败')
     } finally {
       this.loading = false
       m.redraw()
@@ -353,6 +363,18 @@ const RecordForm = {
         })
       ]),
 
+      !this.isEditMode && m('.form-group', [
+        m('label', '自动过期时间（可选）'),
+        m('input', {
+          type: 'datetime-local',
+          value: this.expiresAt,
+          oninput: (e) => { this.expiresAt = e.target.value }
+        }),
+        m('label', {
+          style: 'display: block; font-weight: 400; color: var(--text-gray); font-size: 13px;'
+        }, '到期后记录会从 Provider 与数据库中自动删除，适用于预览环境与临时验证记录')
+      ]),
+
       this.error && m('.error-message', this.error),
     ])
   }
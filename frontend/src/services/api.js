@@ -2,6 +2,23 @@ import m from 'mithril'
 
 const API_BASE = '/api'
 
+// Changes to protected records come back as pending change requests (HTTP 202)
+export const notifyPendingApproval = (response) => {
+  if (response?.change_request) {
+    alert(`该变更需要审批（${response.change_request.reason}），已提交变更请求 #${response.change_request.id}，等待其他用户批准。`)
+  }
+  return response
+}
+
+// Saved records may come back with lint warnings such as a shadowed wildcard
+export const notifyLintWarnings = (response) => {
+  const warnings = response?.warnings || []
+  if (warnings.length > 0) {
+    alert(`记录已保存，但有以下提示：\n${warnings.map((warning) => `- ${warning.message}`).join('\n')}`)
+  }
+  return response
+}
+
 // Auth API
 export const auth = {
   getCurrentUser: () =>
@@ -10,6 +27,13 @@ export const auth = {
       url: `${API_BASE}/auth/user`,
       withCredentials: true,
     }),
+
+  logout: () =>
+    m.request({
+      method: 'POST',
+      url: `${API_BASE}/auth/logout`,
+      withCredentials: true,
+    }),
 }
 
 // Provider API
@@ -61,10 +85,11 @@ export const providers = {
 
 // DNS Record API
 export const records = {
-  list: () =>
+  list: (params) =>
     m.request({
       method: 'GET',
       url: `${API_BASE}/records`,
+      params,
       withCredentials: true,
     }),
 
@@ -128,6 +153,31 @@ export const records = {
     }),
 }
 
+// Server API
+export const servers = {
+  list: () =>
+    m.request({
+      method: 'GET',
+      url: `${API_BASE}/servers`,
+      withCredentials: true,
+    }),
+
+  decommissionPlan: (id) =>
+    m.request({
+      method: 'GET',
+      url: `${API_BASE}/servers/${id}/decommission`,
+      withCredentials: true,
+    }),
+
+  decommission: (id, data) =>
+    m.request({
+      method: 'POST',
+      url: `${API_BASE}/servers/${id}/decommission`,
+      body: data,
+      withCredentials: true,
+    }),
+}
+
 // Audit Log API
 export const auditLogs = {
   list: (params) =>
@@ -137,4 +187,13 @@ export const auditLogs = {
       params,
       withCredentials: true,
     }),
+
+  exportUrl: (params) =>
+    `${API_BASE}/audit-logs/export?${m.buildQueryString(params)}`,
+}
+
+// Live event stream (Server-Sent Events)
+export const eventStream = {
+  open: () =>
+    new EventSource(`${API_BASE}/events/stream`, { withCredentials: true }),
 }
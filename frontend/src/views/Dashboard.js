@@ -1,8 +1,25 @@
 import m from 'mithril'
-import { auth, records } from '../services/api'
+import { auth, records, eventStream, notifyPendingApproval } from '../services/api'
 import ProviderWizard from '../components/ProviderWizard'
 import RecordForm from '../components/RecordForm'
 import AuditLogModal from '../components/AuditLogModal'
+import DecommissionModal from '../components/DecommissionModal'
+
+// Event types that change what the dashboard shows
+const LIVE_EVENT_TYPES = [
+  'record.created',
+  'record.updated',
+  'record.deleted',
+  'record.hidden',
+  'record.enabled',
+  'record.disabled',
+  'provider.created',
+  'provider.updated',
+  'provider.deleted',
+  'provider.synced',
+  'drift.detected',
+  'resync',
+]
 
 const Dashboard = {
   user: null,
@@ -13,12 +30,64 @@ const Dashboard = {
   showProviderWizard: false,
   showRecordForm: false,
   showAuditLogs: false,
+  decommissionServerId: null,
+  query: '',
+  queryError: '',
+  matchCount: 0,
   recordFormContext: null,
   reanalyzing: false,
   activeMenuId: null,
+  events: null,
+  refreshTimer: null,
 
   oninit() {
     this.loadData()
+    this.subscribeEvents()
+  },
+
+  onremove() {
+    if (this.events) {
+      this.events.close()
+      this.events = null
+    }
+    clearTimeout(this.refreshTimer)
+  },
+
+  // Reload records when other users (or a re-analysis) change them.
+  // EventSource reconnects on its own and resumes via Last-Event-ID.
+  subscribeEvents() {
+    if (typeof EventSource === 'undefined') return
+
+    this.events = eventStream.open()
+    LIVE_EVENT_TYPES.forEach((type) => {
+      this.events.addEventListener(type, () => this.scheduleRefresh())
+    })
+  },
+
+  scheduleRefresh() {
+    clearTimeout(this.refreshTimer)
+    this.refreshTimer = setTimeout(() => this.refreshRecords(), 500)
+  },
+
+  async refreshRecords() {
+    try {
+      const recordsResponse = await records.list()
+      this.servers = recordsResponse.servers || []
+      this.unassignedRecords = recordsResponse.unassigned_records || []
+      this.providerCapabilities = recordsResponse.provider_capabilities || {}
+      m.redraw()
+    } catch (error) {
+      console.error('Failed to refresh records:', error)
+    }
+  },
+
+  async logout() {
+    try {
+      const response = await auth.logout()
+      window.location.href = response.logout_url || '/'
+    } catch (error) {
+      console.error('Failed to log out:', error)
+    }
   },
 
   async loadData() {
@@ -30,13 +99,25 @@ const Dashboard = {
       this.user = response.user
 
       // Load records (new server-first structure)
-      const recordsResponse = await records.list()
+      const recordsResponse = await records.list(this.query ? { q: this.query } : undefined)
       this.servers = recordsResponse.servers || []
       this.unassignedRecords = recordsResponse.unassigned_records || []
       this.providerCapabilities = recordsResponse.provider_capabilities || {}
+      this.matchCount = recordsResponse.total || 0
+      this.queryError = ''
     } catch (error) {
       console.error('Failed to load data:', error)
+      if (error.code === 400 && this.query) {
+        this.queryError = error.response?.error || '查询语法错误'
+        return
+      }
       if (error.code === 401) {
+        // Native OIDC login: send the browser to the identity provider
+        if (error.response?.login_url) {
+          const redirect = window.location.pathname + window.location.hash
+          window.location.href = `${error.response.login_url}?redirect=${encodeURIComponent(redirect)}`
+          return
+        }
         alert('身份验证失败，请确保通过反向代理访问')
       }
     } finally {
@@ -60,7 +141,7 @@ const Dashboard = {
     if (!confirm('确定要删除此记录吗？此操作将从 DNS Provider 中删除记录，无法恢复！')) return
 
     try {
-      await records.delete(recordId)
+      notifyPendingApproval(await records.delete(recordId))
       await this.loadData()
     } catch (error) {
       alert('删除失败: ' + (error.response?.error || error.message))
@@ -71,7 +152,7 @@ const Dashboard = {
     if (!confirm('确定要取消服务器标记吗？取消后将作为普通解析记录处理。')) return
 
     try {
-      await records.update(record.id, {
+      notifyPendingApproval(await records.update(record.id, {
         provider_id: record.provider_id,
         zone_id: record.zone_id,
         zone_name: record.zone_name,
@@ -83,7 +164,7 @@ const Dashboard = {
         is_server: false,
         server_name: '',
         server_region: '',
-      })
+      }))
       await this.loadData()
     } catch (error) {
       alert('取消服务器失败: ' + (error.response?.error || error.message))
@@ -105,9 +186,9 @@ const Dashboard = {
 
     try {
       if (enable) {
-        await records.enable(record.id)
+        notifyPendingApproval(await records.enable(record.id))
       } else {
-        await records.disable(record.id)
+        notifyPendingApproval(await records.disable(record.id))
       }
       await this.loadData()
     } catch (error) {
@@ -177,6 +258,9 @@ const Dashboard = {
           m('h1', 'DNSMesh'),
           m('.header-actions', [
             m('.header-user', `欢迎, ${this.user?.username || 'User'}`),
+            this.user?.auth_method === 'oidc' && m('button.btn.btn-secondary.btn-small', {
+              onclick: () => this.logout()
+            }, '退出登录'),
           ]),
         ]),
       ]),
@@ -208,12 +292,33 @@ const Dashboard = {
               }, '+ 添加 Provider'),
             ]),
             m('.toolbar-right', [
+              m('input.record-search[type=search]', {
+                value: this.query,
+                placeholder: '搜索，如 type:CNAME zone:example.com server:hk-*',
+                title: '支持 type、zone、domain、target、server、region、provider、notes、active、is_server、ttl；* 为通配符，逗号表示或，前缀 - 表示排除',
+                onkeydown: (e) => {
+                  if (e.key === 'Enter') {
+                    this.query = e.target.value.trim()
+                    this.loadData()
+                  }
+                },
+                onsearch: (e) => {
+                  if (!e.target.value && this.query) {
+                    this.query = ''
+                    this.loadData()
+                  }
+                },
+              }),
               m('button.btn.btn-secondary.btn-small', {
                 onclick: () => { this.showAuditLogs = true }
               }, '📜 审计日志'),
             ]),
           ]),
 
+          this.queryError && m('.audit-error', this.queryError),
+          this.query && !this.queryError && !this.loading &&
+            m('.search-summary', `匹配 ${this.matchCount} 条记录`),
+
           this.loading ?
             m('.loading', '加载中...') :
             this.servers.length === 0 && this.unassignedRecords.length === 0 ?
@@ -250,6 +355,12 @@ const Dashboard = {
       this.showAuditLogs && m(AuditLogModal, {
         onClose: () => { this.showAuditLogs = false }
       }),
+
+      this.decommissionServerId && m(DecommissionModal, {
+        serverId: this.decommissionServerId,
+        onClose: () => { this.decommissionServerId = null },
+        onComplete: () => { this.loadData() },
+      }),
     ]
   },
 
@@ -262,7 +373,7 @@ const Dashboard = {
             m('.server-info', [
               m('span.server-icon', '🖥️'),
               m('span.server-name', [
-                serverGroup.server.server_name || serverGroup.server.full_domain,
+                serverGroup.server_info?.name || serverGroup.server.server_name || serverGroup.server.full_domain,
                 m('a.record-domain-link', {
                   href: this.buildDomainUrl(serverGroup.server.full_domain),
                   target: '_blank',
@@ -271,7 +382,8 @@ const Dashboard = {
                 }, '↗'),
               ]),
               m('span.server-meta', [
-                serverGroup.server.server_region && m('span', serverGroup.server.server_region + ' '),
+                (serverGroup.server_info?.region || serverGroup.server.server_region) &&
+                  m('span', (serverGroup.server_info?.region || serverGroup.server.server_region) + ' '),
                 m('span.inline-status-group', [
                   m('a', {
                     class: 'record-domain-inline',
@@ -313,6 +425,12 @@ const Dashboard = {
                       this.handleHideRecord(serverGroup.server.id)
                     }
                   }, '隐藏服务器'),
+                  serverGroup.server_info && m('button.action-item.danger', {
+                    onclick: () => {
+                      this.closeMenu()
+                      this.decommissionServerId = serverGroup.server_info.id
+                    }
+                  }, '下线服务器'),
                 ]),
             ]),
           ]),